@@ -8,12 +8,16 @@ import (
 )
 
 type query struct {
-	table     string
-	projected *selectClause
-	filters   []*whereClause
-	orderBy   *orderbyClause
-	groupBy   *groupByClause
-	joins     []*joinClause
+	table      string
+	projected  *selectClause
+	filters    []*whereClause
+	orderBy    *orderbyClause
+	groupBy    *groupByClause
+	joins      []*joinClause
+	limit      int
+	limitSet   bool
+	offset     int
+	offsetSet  bool
 }
 
 type whereClause struct {
@@ -120,6 +124,20 @@ func (q *query) Where(parts ...string) *whereClause {
 	return w
 }
 
+// Limit caps the number of rows the query returns.
+func (q *query) Limit(n int) *query {
+	q.limit = n
+	q.limitSet = true
+	return q
+}
+
+// Offset skips the first n rows the query would otherwise return.
+func (q *query) Offset(n int) *query {
+	q.offset = n
+	q.offsetSet = true
+	return q
+}
+
 func (q *query) OrderBy(columns ...string) *orderbyClause {
 	q.orderBy = &orderbyClause{
 		parent:  q,
@@ -168,6 +186,14 @@ func (q *query) SQL() (string, error) {
 		}
 	}
 
+	if q.limitSet {
+		sections = append(sections, fmt.Sprintf("LIMIT %d", q.limit))
+	}
+
+	if q.offsetSet {
+		sections = append(sections, fmt.Sprintf("OFFSET %d", q.offset))
+	}
+
 	return strings.Join(sections, " "), nil
 }
 
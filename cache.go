@@ -0,0 +1,364 @@
+package orm
+
+import (
+	"container/list"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cacher is the second-level query cache consulted by the read paths
+// (Find, Query, QueryRaw) and invalidated by the write paths (Insert,
+// Update, Delete, InsertAll, ExecRaw). It is modeled on xorm's
+// caches.Cacher: GetBean/PutBean/DelBean cache a single row by (table,
+// id); GetIDs/PutIDs/ClearIDs cache the primary keys a query matched, so
+// a cached query can hydrate its rows back out of the bean cache instead
+// of re-running SQL. ClearBeans drops every row cached for table, which
+// is as precise as a raw statement or batch insert can invalidate.
+type Cacher interface {
+	GetBean(table string, id interface{}) (interface{}, bool)
+	PutBean(table string, id interface{}, bean interface{})
+	DelBean(table string, id interface{})
+	GetIDs(table, key string) ([]interface{}, bool)
+	PutIDs(table, key string, ids []interface{})
+	ClearIDs(table string)
+	ClearBeans(table string)
+}
+
+// CacheStore is the key/value storage a Cacher keeps its entries in,
+// factored out so NewLRUCacher can sit on top of something other than
+// NewMemoryStore's in-process map.
+type CacheStore interface {
+	Get(key string) (interface{}, bool)
+	Put(key string, value interface{})
+	Del(key string)
+}
+
+// memoryStore is the CacheStore NewMemoryStore returns: a plain
+// in-process map guarded by a mutex.
+type memoryStore struct {
+	mu   sync.RWMutex
+	data map[string]interface{}
+}
+
+// NewMemoryStore returns a CacheStore backed by an in-process map, for
+// passing to NewLRUCacher when the caller has nothing external to plug
+// in. NewLRUCacher has no default store of its own.
+func NewMemoryStore() CacheStore {
+	return &memoryStore{data: map[string]interface{}{}}
+}
+
+func (s *memoryStore) Get(key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.data[key]
+	return v, ok
+}
+
+func (s *memoryStore) Put(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+}
+
+func (s *memoryStore) Del(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+}
+
+// cacheEntry is what an lruCacher actually stores in its CacheStore, so
+// a zero expireAt (maxAge <= 0) can mean "never expires".
+type cacheEntry struct {
+	value    interface{}
+	expireAt time.Time
+}
+
+// lruCacher is the default Cacher: an LRU eviction policy and an
+// optional TTL layered on top of any CacheStore, the same (store,
+// maxAge, maxElements) shape as xorm's caches.NewLRUCacher.
+type lruCacher struct {
+	mu          sync.Mutex
+	store       CacheStore
+	maxAge      time.Duration
+	maxElements int
+	order       *list.List
+	elements    map[string]*list.Element
+	// tableKeys and keyTable let ClearIDs/ClearBeans bust every key that
+	// touches a table without the CacheStore having to support iteration.
+	tableKeys map[string]map[string]struct{}
+	keyTable  map[string]string
+}
+
+// NewLRUCacher wires an LRU eviction policy and a TTL on top of store;
+// maxAge <= 0 disables expiry and maxElements <= 0 disables eviction.
+func NewLRUCacher(store CacheStore, maxAge time.Duration, maxElements int) Cacher {
+	return &lruCacher{
+		store:       store,
+		maxAge:      maxAge,
+		maxElements: maxElements,
+		order:       list.New(),
+		elements:    map[string]*list.Element{},
+		tableKeys:   map[string]map[string]struct{}{},
+		keyTable:    map[string]string{},
+	}
+}
+
+func idKey(table string, id interface{}) string {
+	return fmt.Sprintf("bean:%s:%v", table, id)
+}
+
+func queryKey(table, key string) string {
+	return fmt.Sprintf("ids:%s:%s", table, key)
+}
+
+func (c *lruCacher) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	raw, ok := c.store.Get(key)
+	if !ok {
+		return nil, false
+	}
+	entry, ok := raw.(cacheEntry)
+	if !ok {
+		return nil, false
+	}
+	if c.maxAge > 0 && time.Now().After(entry.expireAt) {
+		c.removeLocked(key)
+		return nil, false
+	}
+	c.touchLocked(key)
+	return entry.value, true
+}
+
+func (c *lruCacher) put(table, key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var expireAt time.Time
+	if c.maxAge > 0 {
+		expireAt = time.Now().Add(c.maxAge)
+	}
+	c.store.Put(key, cacheEntry{value: value, expireAt: expireAt})
+	if _, ok := c.tableKeys[table]; !ok {
+		c.tableKeys[table] = map[string]struct{}{}
+	}
+	c.tableKeys[table][key] = struct{}{}
+	c.keyTable[key] = table
+	c.touchLocked(key)
+	c.evictLocked()
+}
+
+func (c *lruCacher) touchLocked(key string) {
+	if el, ok := c.elements[key]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+	c.elements[key] = c.order.PushFront(key)
+}
+
+func (c *lruCacher) removeLocked(key string) {
+	if el, ok := c.elements[key]; ok {
+		c.order.Remove(el)
+		delete(c.elements, key)
+	}
+	c.store.Del(key)
+	if table, ok := c.keyTable[key]; ok {
+		delete(c.tableKeys[table], key)
+		delete(c.keyTable, key)
+	}
+}
+
+func (c *lruCacher) evictLocked() {
+	for c.maxElements > 0 && c.order.Len() > c.maxElements {
+		oldest := c.order.Back()
+		if oldest == nil {
+			return
+		}
+		c.removeLocked(oldest.Value.(string))
+	}
+}
+
+func (c *lruCacher) clearPrefix(table, prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.tableKeys[table] {
+		if strings.HasPrefix(key, prefix) {
+			c.removeLocked(key)
+		}
+	}
+}
+
+func (c *lruCacher) GetBean(table string, id interface{}) (interface{}, bool) {
+	return c.get(idKey(table, id))
+}
+
+func (c *lruCacher) PutBean(table string, id interface{}, bean interface{}) {
+	c.put(table, idKey(table, id), bean)
+}
+
+func (c *lruCacher) DelBean(table string, id interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(idKey(table, id))
+}
+
+func (c *lruCacher) GetIDs(table, key string) ([]interface{}, bool) {
+	v, ok := c.get(queryKey(table, key))
+	if !ok {
+		return nil, false
+	}
+	ids, ok := v.([]interface{})
+	return ids, ok
+}
+
+func (c *lruCacher) PutIDs(table, key string, ids []interface{}) {
+	c.put(table, queryKey(table, key), ids)
+}
+
+func (c *lruCacher) ClearIDs(table string) {
+	c.clearPrefix(table, "ids:")
+}
+
+func (c *lruCacher) ClearBeans(table string) {
+	c.clearPrefix(table, "bean:")
+}
+
+// CacheableEntity is the per-entity opt-in the cache checks before it
+// ever touches a row: Connection.SetDefaultCacher wires a Cacher in
+// globally, and an Entity still has to implement CacheableEntity to have
+// its rows actually cached, the same two-level opt-in RegisterCallback
+// already uses for lifecycle hooks (a global chain, an entity-level
+// hook).
+type CacheableEntity interface {
+	Cacheable() bool
+}
+
+func isCacheable(obj Entity) bool {
+	c, ok := obj.(CacheableEntity)
+	return ok && c.Cacheable()
+}
+
+func cacherFor(md *schema) Cacher {
+	return md.getConnection().cacher
+}
+
+// invalidateRow drops obj's own cached row along with every cached query
+// on its table, since a changed row can flip in or out of a query's
+// result set the cache has no way to re-evaluate.
+func invalidateRow(obj Entity) {
+	md := getSchemaFor(obj)
+	cacher := cacherFor(md)
+	if cacher == nil {
+		return
+	}
+	cacher.DelBean(md.Table, genericGetPKValue(obj))
+	cacher.ClearIDs(md.Table)
+}
+
+// invalidateTable busts every row and query cached for md's table. It's
+// the only safe response to a write whose affected rows the cache can't
+// enumerate, such as ExecRaw or a batch InsertAll.
+func invalidateTable(md *schema) {
+	cacher := cacherFor(md)
+	if cacher == nil {
+		return
+	}
+	cacher.ClearBeans(md.Table)
+	cacher.ClearIDs(md.Table)
+}
+
+// invalidateQueries busts cached queries on md's table without touching
+// any cached row, for writes like Insert whose new row can't collide
+// with anything already cached by PK but could still match an existing
+// query's result set.
+func invalidateQueries(md *schema) {
+	cacher := cacherFor(md)
+	if cacher == nil {
+		return
+	}
+	cacher.ClearIDs(md.Table)
+}
+
+// cacheBoundRows populates the row cache for every cacheable entity just
+// bound into output, mirroring the single/slice shapes runAfterFind
+// already handles for the same output value.
+func cacheBoundRows(md *schema, output interface{}) {
+	cacher := cacherFor(md)
+	if cacher == nil {
+		return
+	}
+	v := reflect.ValueOf(output)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			cacheRow(cacher, md, v.Index(i))
+		}
+	case reflect.Struct:
+		cacheRow(cacher, md, v)
+	}
+}
+
+func cacheRow(cacher Cacher, md *schema, v reflect.Value) {
+	obj := entityAt(v)
+	if obj == nil || !isCacheable(obj) {
+		return
+	}
+	cacher.PutBean(md.Table, genericGetPKValue(obj), v.Interface())
+}
+
+// queryCacheKey derives the key Query/QueryRaw cache a result set under,
+// folding args into the string so that two calls with identical SQL and
+// arguments land on the same key.
+func queryCacheKey(q string, args []interface{}) string {
+	return fmt.Sprintf("%s|%v", q, args)
+}
+
+// queryFromCache looks up a previously cached Query/QueryRaw result for
+// (q, args) and hydrates it from the row cache. A miss anywhere along
+// the way -- no cached id list, or an id that fell out of the row cache
+// -- reports false so the caller falls back to running q.
+func queryFromCache[OUTPUT Entity](md *schema, q string, args []interface{}) ([]OUTPUT, bool) {
+	cacher := cacherFor(md)
+	if cacher == nil || !isCacheable(*new(OUTPUT)) {
+		return nil, false
+	}
+	ids, ok := cacher.GetIDs(md.Table, queryCacheKey(q, args))
+	if !ok {
+		return nil, false
+	}
+	out := make([]OUTPUT, 0, len(ids))
+	for _, id := range ids {
+		bean, ok := cacher.GetBean(md.Table, id)
+		if !ok {
+			return nil, false
+		}
+		row, ok := bean.(OUTPUT)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, row)
+	}
+	return out, true
+}
+
+// cacheQueryResult caches output under (q, args) as a list of primary
+// keys, and caches each row individually so later lookups -- by Find or
+// by another query sharing a row -- can hit the same bean cache entry.
+func cacheQueryResult[OUTPUT Entity](md *schema, q string, args []interface{}, output []OUTPUT) {
+	cacher := cacherFor(md)
+	if cacher == nil || !isCacheable(*new(OUTPUT)) {
+		return
+	}
+	ids := make([]interface{}, len(output))
+	for i, row := range output {
+		id := genericGetPKValue(row)
+		ids[i] = id
+		cacher.PutBean(md.Table, id, row)
+	}
+	cacher.PutIDs(md.Table, queryCacheKey(q, args), ids)
+}
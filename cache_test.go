@@ -0,0 +1,81 @@
+package orm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUCacherBeanRoundTrip(t *testing.T) {
+	c := NewLRUCacher(NewMemoryStore(), 0, 0)
+
+	if _, ok := c.GetBean("posts", 1); ok {
+		t.Fatal("expected a miss before anything was cached")
+	}
+
+	c.PutBean("posts", 1, "row-1")
+	bean, ok := c.GetBean("posts", 1)
+	if !ok || bean != "row-1" {
+		t.Fatalf("GetBean = (%v, %v), want (row-1, true)", bean, ok)
+	}
+
+	c.DelBean("posts", 1)
+	if _, ok := c.GetBean("posts", 1); ok {
+		t.Fatal("expected a miss after DelBean")
+	}
+}
+
+func TestLRUCacherIDsAndClearIDs(t *testing.T) {
+	c := NewLRUCacher(NewMemoryStore(), 0, 0)
+
+	c.PutIDs("posts", "where published", []interface{}{1, 2, 3})
+	ids, ok := c.GetIDs("posts", "where published")
+	if !ok || len(ids) != 3 {
+		t.Fatalf("GetIDs = (%v, %v), want 3 ids", ids, ok)
+	}
+
+	c.ClearIDs("posts")
+	if _, ok := c.GetIDs("posts", "where published"); ok {
+		t.Fatal("expected ClearIDs to drop the cached id list")
+	}
+}
+
+func TestLRUCacherClearBeansOnlyTouchesItsTable(t *testing.T) {
+	c := NewLRUCacher(NewMemoryStore(), 0, 0)
+	c.PutBean("posts", 1, "post-1")
+	c.PutBean("authors", 1, "author-1")
+
+	c.ClearBeans("posts")
+
+	if _, ok := c.GetBean("posts", 1); ok {
+		t.Error("expected posts bean to be cleared")
+	}
+	if _, ok := c.GetBean("authors", 1); !ok {
+		t.Error("ClearBeans(\"posts\") must not touch the authors table")
+	}
+}
+
+func TestLRUCacherMaxElementsEvictsOldest(t *testing.T) {
+	c := NewLRUCacher(NewMemoryStore(), 0, 2)
+
+	c.PutBean("posts", 1, "post-1")
+	c.PutBean("posts", 2, "post-2")
+	c.PutBean("posts", 3, "post-3")
+
+	if _, ok := c.GetBean("posts", 1); ok {
+		t.Error("expected the oldest entry to be evicted once maxElements was exceeded")
+	}
+	if _, ok := c.GetBean("posts", 3); !ok {
+		t.Error("expected the most recently inserted entry to survive")
+	}
+}
+
+func TestLRUCacherMaxAgeExpires(t *testing.T) {
+	c := NewLRUCacher(NewMemoryStore(), time.Millisecond, 0)
+	c.PutBean("posts", 1, "post-1")
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := c.GetBean("posts", 1); ok {
+		t.Error("expected the bean to have expired after maxAge elapsed")
+	}
+}
@@ -0,0 +1,300 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Lifecycle events a Connection runs callbacks for. Entities opt in by
+// implementing the matching BeforeXxx/AfterXxx interface below; callers
+// opt in per-Connection with RegisterCallback.
+const (
+	EventBeforeInsert = "before_insert"
+	EventAfterInsert  = "after_insert"
+	EventBeforeUpdate = "before_update"
+	EventAfterUpdate  = "after_update"
+	EventBeforeDelete = "before_delete"
+	EventAfterDelete  = "after_delete"
+	EventAfterFind    = "after_find"
+)
+
+// entityCallbackName is the chain slot occupied by the Entity's own
+// BeforeXxx/AfterXxx method (if it implements one), so that globally
+// registered callbacks can be ordered relative to it with Before/After.
+const entityCallbackName = "entity"
+
+// CallbackFunc is a callback registered on a Connection for a lifecycle
+// event. Returning a non-nil error aborts the in-flight operation before
+// any SQL runs (for Before* events) or propagates it to the caller
+// (for After* events); the error is never swallowed.
+type CallbackFunc func(ctx context.Context, obj Entity) error
+
+type callbackEntry struct {
+	name string
+	fn   CallbackFunc
+}
+
+// callbackChain is the ordered list of named callbacks registered for a
+// single event on a Connection.
+type callbackChain struct {
+	entries []callbackEntry
+}
+
+func (c *callbackChain) indexOf(name string) int {
+	for i, e := range c.entries {
+		if e.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *callbackChain) upsert(entry callbackEntry, before, after string) error {
+	if idx := c.indexOf(entry.name); idx != -1 {
+		c.entries[idx] = entry
+		return nil
+	}
+	switch {
+	case before != "":
+		idx := c.indexOf(before)
+		if idx == -1 {
+			return fmt.Errorf("orm: no callback named %q registered to insert before", before)
+		}
+		c.entries = append(c.entries[:idx:idx], append([]callbackEntry{entry}, c.entries[idx:]...)...)
+	case after != "":
+		idx := c.indexOf(after)
+		if idx == -1 {
+			return fmt.Errorf("orm: no callback named %q registered to insert after", after)
+		}
+		c.entries = append(c.entries[:idx+1:idx+1], append([]callbackEntry{entry}, c.entries[idx+1:]...)...)
+	default:
+		c.entries = append(c.entries, entry)
+	}
+	return nil
+}
+
+func (c *callbackChain) run(ctx context.Context, obj Entity) error {
+	if c == nil {
+		return nil
+	}
+	for _, e := range c.entries {
+		if e.fn == nil {
+			continue
+		}
+		if err := e.fn(ctx, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CallbackOption positions a callback passed to RegisterCallback relative
+// to one already registered for the same event.
+type CallbackOption func(*callbackPosition)
+
+type callbackPosition struct {
+	before string
+	after  string
+}
+
+// Before runs the registered callback immediately before the callback
+// named name, which may be the reserved "entity" slot an Entity's own
+// hook method occupies.
+func Before(name string) CallbackOption {
+	return func(p *callbackPosition) { p.before = name }
+}
+
+// After runs the registered callback immediately after the callback
+// named name, which may be the reserved "entity" slot an Entity's own
+// hook method occupies.
+func After(name string) CallbackOption {
+	return func(p *callbackPosition) { p.after = name }
+}
+
+// RegisterCallback adds fn under name to the chain that runs for event on
+// every Entity handled by this Connection, e.g. to stamp auditing
+// columns like created_at/updated_at regardless of concrete Entity type.
+// Registering again under a name already in use replaces it in place.
+//
+// A freshly created chain has its "entity" slot seeded immediately, so
+// Before("entity")/After("entity") work even when called before any
+// operation has run for event.
+func (d *Connection) RegisterCallback(event, name string, fn CallbackFunc, opts ...CallbackOption) error {
+	var pos callbackPosition
+	for _, opt := range opts {
+		opt(&pos)
+	}
+	d.callbacksMu.Lock()
+	defer d.callbacksMu.Unlock()
+	if d.callbacks == nil {
+		d.callbacks = map[string]*callbackChain{}
+	}
+	chain, ok := d.callbacks[event]
+	if !ok {
+		chain = &callbackChain{}
+		chain.entries = append(chain.entries, callbackEntry{name: entityCallbackName, fn: entityHook(event)})
+		d.callbacks[event] = chain
+	}
+	return chain.upsert(callbackEntry{name: name, fn: fn}, pos.before, pos.after)
+}
+
+// BeforeInsert entities run fn before the INSERT statement executes; a
+// non-nil error aborts the insert.
+type BeforeInsert interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+// AfterInsert entities run fn after the row has been inserted and the
+// primary key has been set on obj.
+type AfterInsert interface {
+	AfterInsert(ctx context.Context) error
+}
+
+// BeforeUpdate entities run fn before the UPDATE statement executes; a
+// non-nil error aborts the update.
+type BeforeUpdate interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterUpdate entities run fn after the UPDATE statement has executed.
+type AfterUpdate interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+// BeforeDelete entities run fn before the DELETE statement executes; a
+// non-nil error aborts the delete.
+type BeforeDelete interface {
+	BeforeDelete(ctx context.Context) error
+}
+
+// AfterDelete entities run fn after the DELETE statement has executed.
+type AfterDelete interface {
+	AfterDelete(ctx context.Context) error
+}
+
+// AfterFind entities run fn once per row after Find, Query, QueryRaw or a
+// relation lookup has bound it.
+type AfterFind interface {
+	AfterFind(ctx context.Context) error
+}
+
+// entityHook returns the CallbackFunc that invokes obj's own hook method
+// for event, or a no-op if obj does not implement the matching interface.
+func entityHook(event string) CallbackFunc {
+	switch event {
+	case EventBeforeInsert:
+		return func(ctx context.Context, obj Entity) error {
+			if h, ok := obj.(BeforeInsert); ok {
+				return h.BeforeInsert(ctx)
+			}
+			return nil
+		}
+	case EventAfterInsert:
+		return func(ctx context.Context, obj Entity) error {
+			if h, ok := obj.(AfterInsert); ok {
+				return h.AfterInsert(ctx)
+			}
+			return nil
+		}
+	case EventBeforeUpdate:
+		return func(ctx context.Context, obj Entity) error {
+			if h, ok := obj.(BeforeUpdate); ok {
+				return h.BeforeUpdate(ctx)
+			}
+			return nil
+		}
+	case EventAfterUpdate:
+		return func(ctx context.Context, obj Entity) error {
+			if h, ok := obj.(AfterUpdate); ok {
+				return h.AfterUpdate(ctx)
+			}
+			return nil
+		}
+	case EventBeforeDelete:
+		return func(ctx context.Context, obj Entity) error {
+			if h, ok := obj.(BeforeDelete); ok {
+				return h.BeforeDelete(ctx)
+			}
+			return nil
+		}
+	case EventAfterDelete:
+		return func(ctx context.Context, obj Entity) error {
+			if h, ok := obj.(AfterDelete); ok {
+				return h.AfterDelete(ctx)
+			}
+			return nil
+		}
+	case EventAfterFind:
+		return func(ctx context.Context, obj Entity) error {
+			if h, ok := obj.(AfterFind); ok {
+				return h.AfterFind(ctx)
+			}
+			return nil
+		}
+	default:
+		return func(context.Context, Entity) error { return nil }
+	}
+}
+
+// runCallbacks runs event's chain on conn for obj, persisting obj's own
+// hook method into the reserved "entity" slot the first time event
+// fires if it isn't there already, so later RegisterCallback calls can
+// position themselves with Before("entity")/After("entity").
+// conn.callbacksMu is held for the whole call, including chain.run, so
+// concurrent Insert/Update/Delete/... calls sharing conn can't race on
+// the chain's entries while one of them is iterating it.
+func runCallbacks(ctx context.Context, conn *Connection, event string, obj Entity) error {
+	conn.callbacksMu.Lock()
+	defer conn.callbacksMu.Unlock()
+	if conn.callbacks == nil {
+		conn.callbacks = map[string]*callbackChain{}
+	}
+	chain := conn.callbacks[event]
+	if chain == nil {
+		chain = &callbackChain{}
+		conn.callbacks[event] = chain
+	}
+	if chain.indexOf(entityCallbackName) == -1 {
+		chain.entries = append(chain.entries, callbackEntry{name: entityCallbackName, fn: entityHook(event)})
+	}
+	return chain.run(ctx, obj)
+}
+
+// runAfterFind fires EventAfterFind for every row bind populated into
+// output, which may point at a single Entity or a slice of them.
+func runAfterFind(ctx context.Context, conn *Connection, output interface{}) error {
+	v := reflect.ValueOf(output)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			obj := entityAt(v.Index(i))
+			if obj == nil {
+				continue
+			}
+			if err := runCallbacks(ctx, conn, EventAfterFind, obj); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		if obj := entityAt(v); obj != nil {
+			return runCallbacks(ctx, conn, EventAfterFind, obj)
+		}
+	}
+	return nil
+}
+
+func entityAt(v reflect.Value) Entity {
+	if v.CanAddr() {
+		if obj, ok := v.Addr().Interface().(Entity); ok {
+			return obj
+		}
+	}
+	if obj, ok := v.Interface().(Entity); ok {
+		return obj
+	}
+	return nil
+}
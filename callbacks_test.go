@@ -0,0 +1,101 @@
+package orm
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestCallbackChainUpsertBeforeAfter(t *testing.T) {
+	chain := &callbackChain{entries: []callbackEntry{{name: entityCallbackName}}}
+
+	if err := chain.upsert(callbackEntry{name: "audit"}, entityCallbackName, ""); err != nil {
+		t.Fatalf("upsert before entity: %v", err)
+	}
+	if idx := chain.indexOf("audit"); idx != 0 {
+		t.Errorf("audit index = %d, want 0 (before entity)", idx)
+	}
+
+	if err := chain.upsert(callbackEntry{name: "notify"}, "", entityCallbackName); err != nil {
+		t.Fatalf("upsert after entity: %v", err)
+	}
+	if idx := chain.indexOf("notify"); idx != 2 {
+		t.Errorf("notify index = %d, want 2 (after entity)", idx)
+	}
+}
+
+func TestCallbackChainUpsertUnknownNameErrors(t *testing.T) {
+	chain := &callbackChain{}
+	if err := chain.upsert(callbackEntry{name: "audit"}, "missing", ""); err == nil {
+		t.Fatal("expected an error positioning against a name that was never registered")
+	}
+}
+
+// TestRunCallbacksPersistsEntitySlot guards against the bug where the
+// "entity" hook was spliced into a throwaway local chain and never
+// written back to conn.callbacks[event], so RegisterCallback's
+// Before("entity")/After("entity") always failed on the very first
+// registration.
+func TestRunCallbacksPersistsEntitySlot(t *testing.T) {
+	conn := &Connection{}
+	ctx := context.Background()
+
+	if err := runCallbacks(ctx, conn, EventBeforeInsert, nil); err != nil {
+		t.Fatalf("runCallbacks: %v", err)
+	}
+
+	var ran []string
+	err := conn.RegisterCallback(EventBeforeInsert, "audit", func(ctx context.Context, obj Entity) error {
+		ran = append(ran, "audit")
+		return nil
+	}, Before(entityCallbackName))
+	if err != nil {
+		t.Fatalf("RegisterCallback with Before(%q) failed: %v", entityCallbackName, err)
+	}
+
+	if err := runCallbacks(ctx, conn, EventBeforeInsert, nil); err != nil {
+		t.Fatalf("runCallbacks: %v", err)
+	}
+	if len(ran) != 1 || ran[0] != "audit" {
+		t.Errorf("ran = %v, want [audit]", ran)
+	}
+}
+
+// TestRegisterCallbackSeedsEntitySlotBeforeAnyRun guards against the bug
+// where the "entity" slot was only ever seeded lazily inside
+// runCallbacks, so Before("entity")/After("entity") failed every time
+// when called at startup, before any operation had run for the event.
+func TestRegisterCallbackSeedsEntitySlotBeforeAnyRun(t *testing.T) {
+	conn := &Connection{}
+
+	err := conn.RegisterCallback(EventBeforeInsert, "audit", func(ctx context.Context, obj Entity) error {
+		return nil
+	}, Before(entityCallbackName))
+	if err != nil {
+		t.Fatalf("RegisterCallback with Before(%q) on a fresh chain failed: %v", entityCallbackName, err)
+	}
+
+	if idx := conn.callbacks[EventBeforeInsert].indexOf("audit"); idx != 0 {
+		t.Errorf("audit index = %d, want 0 (before entity)", idx)
+	}
+}
+
+// TestRunCallbacksConcurrentSafe guards against the bug where
+// runCallbacks lazily created chains and appended the entity slot into
+// conn.callbacks with no synchronization, so concurrent callers sharing
+// a Connection (the normal way a *sql.DB-backed library is used) could
+// hit "concurrent map writes". Run with -race to catch a regression.
+func TestRunCallbacksConcurrentSafe(t *testing.T) {
+	conn := &Connection{}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = runCallbacks(ctx, conn, EventBeforeInsert, nil)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,253 @@
+package orm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golobby/orm/querybuilder"
+)
+
+// Cond is a typed WHERE condition that renders itself through a
+// Dialect, so operators such as case-insensitive matching or the
+// placeholder style (numbered "$1" vs plain "?") stay portable across
+// SQLite, MySQL and PostgreSQL. Build trees with Exact, Contains, In,
+// Between and friends, combined with And, Or and Not.
+type Cond interface {
+	render(d *querybuilder.Dialect, argIndex *int) (string, []interface{})
+}
+
+type condKind int
+
+const (
+	condExact condKind = iota
+	condIExact
+	condContains
+	condIContains
+	condStartsWith
+	condEndsWith
+	condIStartsWith
+	condIEndsWith
+	condGt
+	condGte
+	condLt
+	condLte
+	condIn
+	condBetween
+	condIsNull
+	condAnd
+	condOr
+	condNot
+	condInSubquery
+)
+
+type cond struct {
+	kind     condKind
+	column   string
+	args     []interface{}
+	children []Cond
+	// raw holds the condInSubquery subquery template: a SQL string with
+	// exactly one %s verb marking where the bound arg's placeholder goes.
+	raw string
+}
+
+// Exact renders "column = ?".
+func Exact(column string, value interface{}) Cond {
+	return &cond{kind: condExact, column: column, args: []interface{}{value}}
+}
+
+// IExact renders a case-insensitive equality check.
+func IExact(column string, value interface{}) Cond {
+	return &cond{kind: condIExact, column: column, args: []interface{}{value}}
+}
+
+// Contains renders "column LIKE %value%".
+func Contains(column string, value string) Cond {
+	return &cond{kind: condContains, column: column, args: []interface{}{"%" + value + "%"}}
+}
+
+// IContains renders a case-insensitive "contains" check.
+func IContains(column string, value string) Cond {
+	return &cond{kind: condIContains, column: column, args: []interface{}{"%" + value + "%"}}
+}
+
+// StartsWith renders "column LIKE value%".
+func StartsWith(column string, value string) Cond {
+	return &cond{kind: condStartsWith, column: column, args: []interface{}{value + "%"}}
+}
+
+// EndsWith renders "column LIKE %value".
+func EndsWith(column string, value string) Cond {
+	return &cond{kind: condEndsWith, column: column, args: []interface{}{"%" + value}}
+}
+
+// IStartsWith renders a case-insensitive "starts with" check.
+func IStartsWith(column string, value string) Cond {
+	return &cond{kind: condIStartsWith, column: column, args: []interface{}{value + "%"}}
+}
+
+// IEndsWith renders a case-insensitive "ends with" check.
+func IEndsWith(column string, value string) Cond {
+	return &cond{kind: condIEndsWith, column: column, args: []interface{}{"%" + value}}
+}
+
+// Gt renders "column > ?".
+func Gt(column string, value interface{}) Cond {
+	return &cond{kind: condGt, column: column, args: []interface{}{value}}
+}
+
+// Gte renders "column >= ?".
+func Gte(column string, value interface{}) Cond {
+	return &cond{kind: condGte, column: column, args: []interface{}{value}}
+}
+
+// Lt renders "column < ?".
+func Lt(column string, value interface{}) Cond {
+	return &cond{kind: condLt, column: column, args: []interface{}{value}}
+}
+
+// Lte renders "column <= ?".
+func Lte(column string, value interface{}) Cond {
+	return &cond{kind: condLte, column: column, args: []interface{}{value}}
+}
+
+// In renders "column IN (?, ?, ...)", expanding to one placeholder per value.
+func In(column string, values ...interface{}) Cond {
+	return &cond{kind: condIn, column: column, args: values}
+}
+
+// Between renders "column BETWEEN ? AND ?".
+func Between(column string, lower, upper interface{}) Cond {
+	return &cond{kind: condBetween, column: column, args: []interface{}{lower, upper}}
+}
+
+// IsNull renders "column IS NULL" or, with isNull false, "column IS NOT NULL".
+func IsNull(column string, isNull bool) Cond {
+	return &cond{kind: condIsNull, column: column, args: []interface{}{isNull}}
+}
+
+// InSubquery renders "column IN (subquery)", where subquery is a SQL
+// template with exactly one %s verb marking where arg's dialect-aware
+// placeholder goes, e.g.
+//
+//	InSubquery("id", "SELECT post_id FROM post_tags WHERE tag_id = %s", tagID)
+func InSubquery(column, subquery string, arg interface{}) Cond {
+	return &cond{kind: condInSubquery, column: column, args: []interface{}{arg}, raw: subquery}
+}
+
+// And combines conds with AND, parenthesizing each.
+func And(conds ...Cond) Cond {
+	return &cond{kind: condAnd, children: conds}
+}
+
+// Or combines conds with OR, parenthesizing each.
+func Or(conds ...Cond) Cond {
+	return &cond{kind: condOr, children: conds}
+}
+
+// Not negates c.
+func Not(c Cond) Cond {
+	return &cond{kind: condNot, children: []Cond{c}}
+}
+
+func isPostgres(d *querybuilder.Dialect) bool {
+	return d != nil && d.DriverName == "postgres"
+}
+
+func nextPlaceholder(d *querybuilder.Dialect, argIndex *int) string {
+	ph := d.PlaceholderChar
+	if d.IncludeIndexInPlaceholder {
+		ph += strconv.Itoa(*argIndex)
+	}
+	*argIndex++
+	return ph
+}
+
+func (c *cond) render(d *querybuilder.Dialect, argIndex *int) (string, []interface{}) {
+	switch c.kind {
+	case condExact:
+		ph := nextPlaceholder(d, argIndex)
+		return fmt.Sprintf("%s = %s", c.column, ph), []interface{}{c.args[0]}
+
+	case condIExact:
+		ph := nextPlaceholder(d, argIndex)
+		if isPostgres(d) {
+			return fmt.Sprintf("%s ILIKE %s", c.column, ph), []interface{}{c.args[0]}
+		}
+		return fmt.Sprintf("LOWER(%s) = LOWER(%s)", c.column, ph), []interface{}{c.args[0]}
+
+	case condContains, condStartsWith, condEndsWith:
+		ph := nextPlaceholder(d, argIndex)
+		return fmt.Sprintf("%s LIKE %s", c.column, ph), []interface{}{c.args[0]}
+
+	case condIContains, condIStartsWith, condIEndsWith:
+		ph := nextPlaceholder(d, argIndex)
+		if isPostgres(d) {
+			return fmt.Sprintf("%s ILIKE %s", c.column, ph), []interface{}{c.args[0]}
+		}
+		return fmt.Sprintf("LOWER(%s) LIKE LOWER(%s)", c.column, ph), []interface{}{c.args[0]}
+
+	case condGt:
+		ph := nextPlaceholder(d, argIndex)
+		return fmt.Sprintf("%s > %s", c.column, ph), []interface{}{c.args[0]}
+
+	case condGte:
+		ph := nextPlaceholder(d, argIndex)
+		return fmt.Sprintf("%s >= %s", c.column, ph), []interface{}{c.args[0]}
+
+	case condLt:
+		ph := nextPlaceholder(d, argIndex)
+		return fmt.Sprintf("%s < %s", c.column, ph), []interface{}{c.args[0]}
+
+	case condLte:
+		ph := nextPlaceholder(d, argIndex)
+		return fmt.Sprintf("%s <= %s", c.column, ph), []interface{}{c.args[0]}
+
+	case condIn:
+		phs := make([]string, len(c.args))
+		for i := range c.args {
+			phs[i] = nextPlaceholder(d, argIndex)
+		}
+		return fmt.Sprintf("%s IN (%s)", c.column, strings.Join(phs, ", ")), append([]interface{}{}, c.args...)
+
+	case condBetween:
+		lo := nextPlaceholder(d, argIndex)
+		hi := nextPlaceholder(d, argIndex)
+		return fmt.Sprintf("%s BETWEEN %s AND %s", c.column, lo, hi), []interface{}{c.args[0], c.args[1]}
+
+	case condIsNull:
+		if isNull, _ := c.args[0].(bool); isNull {
+			return fmt.Sprintf("%s IS NULL", c.column), nil
+		}
+		return fmt.Sprintf("%s IS NOT NULL", c.column), nil
+
+	case condAnd, condOr:
+		sep := " AND "
+		if c.kind == condOr {
+			sep = " OR "
+		}
+		var parts []string
+		var args []interface{}
+		for _, child := range c.children {
+			s, a := child.render(d, argIndex)
+			parts = append(parts, "("+s+")")
+			args = append(args, a...)
+		}
+		return strings.Join(parts, sep), args
+
+	case condNot:
+		s, a := c.children[0].render(d, argIndex)
+		return fmt.Sprintf("NOT (%s)", s), a
+
+	case condInSubquery:
+		ph := nextPlaceholder(d, argIndex)
+		return fmt.Sprintf("%s IN (%s)", c.column, fmt.Sprintf(c.raw, ph)), []interface{}{c.args[0]}
+	}
+	return "", nil
+}
+
+// renderCond renders c against d starting at placeholder index 1.
+func renderCond(d *querybuilder.Dialect, c Cond) (string, []interface{}) {
+	argIndex := 1
+	return c.render(d, &argIndex)
+}
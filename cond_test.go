@@ -0,0 +1,66 @@
+package orm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golobby/orm/querybuilder"
+)
+
+var pgDialect = &querybuilder.Dialect{
+	DriverName:                "postgres",
+	PlaceholderChar:           "$",
+	IncludeIndexInPlaceholder: true,
+}
+
+var sqliteDialect = &querybuilder.Dialect{
+	DriverName:                "sqlite3",
+	PlaceholderChar:           "?",
+	IncludeIndexInPlaceholder: false,
+}
+
+func TestRenderCondSingle(t *testing.T) {
+	cases := []struct {
+		name    string
+		dialect *querybuilder.Dialect
+		cond    Cond
+		wantSQL string
+		wantArg []interface{}
+	}{
+		{"postgres exact", pgDialect, Exact("id", 1), "id = $1", []interface{}{1}},
+		{"sqlite exact", sqliteDialect, Exact("id", 1), "id = ?", []interface{}{1}},
+		{"postgres between", pgDialect, Between("age", 1, 2), "age BETWEEN $1 AND $2", []interface{}{1, 2}},
+		{"postgres in", pgDialect, In("id", 1, 2, 3), "id IN ($1, $2, $3)", []interface{}{1, 2, 3}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gotSQL, gotArgs := renderCond(c.dialect, c.cond)
+			if gotSQL != c.wantSQL {
+				t.Errorf("SQL = %q, want %q", gotSQL, c.wantSQL)
+			}
+			if !reflect.DeepEqual(gotArgs, c.wantArg) {
+				t.Errorf("args = %v, want %v", gotArgs, c.wantArg)
+			}
+		})
+	}
+}
+
+// TestRenderCondSharedArgIndex guards against the bug where every
+// WhereCond call restarted placeholder numbering at 1: two conds
+// rendered against one shared argIndex, the way a builder's chained
+// WhereCond calls now do, must keep numbering across both.
+func TestRenderCondSharedArgIndex(t *testing.T) {
+	argIndex := 1
+	firstSQL, firstArgs := Exact("id", 1).render(pgDialect, &argIndex)
+	secondSQL, secondArgs := Exact("published", true).render(pgDialect, &argIndex)
+
+	if firstSQL != "id = $1" {
+		t.Errorf("first SQL = %q, want %q", firstSQL, "id = $1")
+	}
+	if secondSQL != "published = $2" {
+		t.Errorf("second SQL = %q, want %q", secondSQL, "published = $2")
+	}
+	if !reflect.DeepEqual(firstArgs, []interface{}{1}) || !reflect.DeepEqual(secondArgs, []interface{}{true}) {
+		t.Errorf("args = %v / %v, want [1] / [true]", firstArgs, secondArgs)
+	}
+}
@@ -0,0 +1,34 @@
+package orm
+
+// EntityConfigurator is the value an Entity's ConfigureEntity method
+// configures: its table name via Table, and any indexes AutoMigrate
+// should create alongside it via Index/UniqueIndex.
+type EntityConfigurator struct {
+	table   string
+	indexes []Index
+}
+
+func newEntityConfigurator() *EntityConfigurator {
+	return &EntityConfigurator{}
+}
+
+// Table sets the entity's table name; ConfigureEntity must call this,
+// or initTableName fails with "table name is mandatory".
+func (c *EntityConfigurator) Table(name string) *EntityConfigurator {
+	c.table = name
+	return c
+}
+
+// Index declares a non-unique index AutoMigrate creates for this
+// entity's table, e.g. Index("idx_posts_author_id", "author_id").
+func (c *EntityConfigurator) Index(name string, columns ...string) *EntityConfigurator {
+	c.indexes = append(c.indexes, Index{Name: name, Columns: columns})
+	return c
+}
+
+// UniqueIndex declares a unique index the same way Index declares a
+// regular one.
+func (c *EntityConfigurator) UniqueIndex(name string, columns ...string) *EntityConfigurator {
+	c.indexes = append(c.indexes, Index{Name: name, Columns: columns, Unique: true})
+	return c
+}
@@ -0,0 +1,276 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/golobby/orm/querybuilder"
+)
+
+// Index is one index AutoMigrate creates for an entity's table, declared
+// in ConfigureEntity via EntityConfigurator.Index/UniqueIndex.
+type Index struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+// MigrationPlan is the DDL PlanMigration computed for one entity's
+// table: empty Statements means the live table already matches the
+// declared schema.
+type MigrationPlan struct {
+	Table      string
+	Statements []string
+}
+
+// PlanMigration diffs each entity's declared schema against the live
+// database and returns the DDL AutoMigrate would run, without running
+// it. Review it with SchematicMigration, or apply it with AutoMigrate.
+func (d *Connection) PlanMigration(ctx context.Context, entities ...Entity) ([]MigrationPlan, error) {
+	var plans []MigrationPlan
+	for _, e := range entities {
+		md := schemaOf(e)
+		table, err := initTableName(e)
+		if err != nil {
+			return nil, err
+		}
+
+		var stmts []string
+		exists, err := tableExists(ctx, d, table)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			stmts = append(stmts, createTableSQL(d.Dialect, table, md))
+		} else {
+			live, err := liveColumns(ctx, d, table)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range md.fields {
+				if f.Virtual || live[f.Name] {
+					continue
+				}
+				if f.OldName != "" && live[f.OldName] {
+					stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", table, f.OldName, f.Name))
+					continue
+				}
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, f.Name, sqlColumnType(d.Dialect, f.Type)))
+			}
+		}
+
+		for _, idx := range entityIndexes(e) {
+			stmts = append(stmts, createIndexSQL(table, idx))
+		}
+
+		plans = append(plans, MigrationPlan{Table: table, Statements: stmts})
+	}
+	return plans, nil
+}
+
+// entityIndexes runs e's ConfigureEntity the same way initTableName
+// does and returns whatever indexes it declared via
+// EntityConfigurator.Index/UniqueIndex.
+func entityIndexes(e Entity) []Index {
+	configurator := newEntityConfigurator()
+	e.ConfigureEntity(configurator)
+	return configurator.indexes
+}
+
+// AutoMigrate applies PlanMigration's statements: CREATE TABLE for an
+// entity with no live table yet, ALTER TABLE ADD COLUMN (or RENAME
+// COLUMN, for a field whose OldName tag matches a live column) for
+// fields the live table is missing, and CREATE INDEX for any indexes
+// declared in ConfigureEntity. It never drops a column a struct stopped
+// declaring -- call DropColumn explicitly for that.
+func (d *Connection) AutoMigrate(entities ...Entity) error {
+	ctx := context.Background()
+	plans, err := d.PlanMigration(ctx, entities...)
+	if err != nil {
+		return err
+	}
+	for _, plan := range plans {
+		for _, stmt := range plan.Statements {
+			if _, err := d.Connection.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("orm: migrating %s: %w", plan.Table, err)
+			}
+		}
+	}
+	return nil
+}
+
+// SchematicMigration prints each entity's pending migration plan, as a
+// report to review before AutoMigrate applies it, the way Schematic
+// prints a Connection's registered schemas.
+func (d *Connection) SchematicMigration(ctx context.Context, entities ...Entity) error {
+	plans, err := d.PlanMigration(ctx, entities...)
+	if err != nil {
+		return err
+	}
+	for _, plan := range plans {
+		fmt.Printf("---- migration plan: %s ----\n", plan.Table)
+		if len(plan.Statements) == 0 {
+			fmt.Println("(up to date)")
+			continue
+		}
+		for _, stmt := range plan.Statements {
+			fmt.Println(stmt)
+		}
+	}
+	return nil
+}
+
+// DropColumn is the explicit, opt-in counterpart AutoMigrate
+// deliberately never calls itself.
+func (d *Connection) DropColumn(ctx context.Context, table, column string) error {
+	_, err := d.Connection.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, column))
+	return err
+}
+
+// tableExists probes for table the same way every dialect already would
+// at query time, rather than branching on each one's own
+// information_schema.tables/sqlite_master query.
+func tableExists(ctx context.Context, d *Connection, table string) (bool, error) {
+	rows, err := d.Connection.QueryContext(ctx, "SELECT 1 FROM "+table+" LIMIT 0")
+	if err != nil {
+		if isMissingTableError(d.Dialect, err) {
+			return false, nil
+		}
+		return false, err
+	}
+	rows.Close()
+	return true, nil
+}
+
+// isMissingTableError reports whether err is the "relation/table does
+// not exist" error table's own dialect raises for a SELECT against a
+// table that hasn't been created yet, as opposed to a real failure (a
+// dropped connection, a permissions error) PlanMigration must surface
+// rather than paper over with a bogus CREATE TABLE.
+func isMissingTableError(d *querybuilder.Dialect, err error) bool {
+	msg := err.Error()
+	switch d.DriverName {
+	case "sqlite3", "sqlite":
+		return strings.Contains(msg, "no such table")
+	case "mysql":
+		return strings.Contains(msg, "doesn't exist")
+	case "postgres":
+		return strings.Contains(msg, "does not exist")
+	default:
+		return false
+	}
+}
+
+// liveColumns reports the column names table currently has, read from
+// information_schema.columns on Postgres/MySQL or PRAGMA table_info on
+// SQLite.
+func liveColumns(ctx context.Context, d *Connection, table string) (map[string]bool, error) {
+	cols := map[string]bool{}
+
+	if d.Dialect.DriverName == "sqlite3" || d.Dialect.DriverName == "sqlite" {
+		rows, err := d.Connection.QueryContext(ctx, "PRAGMA table_info("+table+")")
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var cid, notnull, pk int
+			var name, ctype string
+			var dflt interface{}
+			if err := rows.Scan(&cid, &name, &ctype, &notnull, &dflt, &pk); err != nil {
+				return nil, err
+			}
+			cols[name] = true
+		}
+		return cols, rows.Err()
+	}
+
+	argIndex := 1
+	ph := nextPlaceholder(d.Dialect, &argIndex)
+	q := fmt.Sprintf("SELECT column_name FROM information_schema.columns WHERE table_name = %s", ph)
+	rows, err := d.Connection.QueryContext(ctx, q, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		cols[name] = true
+	}
+	return cols, rows.Err()
+}
+
+// createTableSQL builds a CREATE TABLE statement from md's declared
+// fields, skipping virtual (computed, non-persisted) ones.
+func createTableSQL(d *querybuilder.Dialect, table string, md *schema) string {
+	var cols []string
+	for _, f := range md.fields {
+		if f.Virtual {
+			continue
+		}
+		col := fmt.Sprintf("%s %s", f.Name, sqlColumnType(d, f.Type))
+		if !f.Nullable {
+			col += " NOT NULL"
+		}
+		if f.IsPK {
+			col += " PRIMARY KEY"
+		}
+		cols = append(cols, col)
+	}
+	return fmt.Sprintf("CREATE TABLE %s (%s)", table, strings.Join(cols, ", "))
+}
+
+// createIndexSQL builds a CREATE [UNIQUE] INDEX statement for idx on
+// table. IF NOT EXISTS keeps AutoMigrate idempotent on dialects that
+// support it (Postgres, SQLite); MySQL rejects a duplicate CREATE INDEX
+// instead, the one dialect-specific wrinkle left for the caller to
+// handle via DropColumn-style manual cleanup.
+func createIndexSQL(table string, idx Index) string {
+	kind := "INDEX"
+	if idx.Unique {
+		kind = "UNIQUE INDEX"
+	}
+	return fmt.Sprintf("CREATE %s IF NOT EXISTS %s ON %s (%s)", kind, idx.Name, table, strings.Join(idx.Columns, ", "))
+}
+
+// sqlColumnType maps a field's Go type to d's dialect-specific SQL
+// column type, falling back to the spelling every dialect accepts where
+// SQLite, MySQL and PostgreSQL agree.
+func sqlColumnType(d *querybuilder.Dialect, t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return "INTEGER"
+	case reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "BIGINT"
+	case reflect.Float32, reflect.Float64:
+		switch d.DriverName {
+		case "mysql":
+			return "DOUBLE"
+		case "sqlite3", "sqlite":
+			return "REAL"
+		default:
+			return "DOUBLE PRECISION"
+		}
+	case reflect.Bool:
+		if d.DriverName == "mysql" {
+			return "TINYINT(1)"
+		}
+		return "BOOLEAN"
+	case reflect.String:
+		return "TEXT"
+	case reflect.Struct:
+		if d.DriverName == "mysql" {
+			return "DATETIME"
+		}
+		return "TIMESTAMP"
+	case reflect.Ptr:
+		return sqlColumnType(d, t.Elem())
+	default:
+		return "TEXT"
+	}
+}
@@ -0,0 +1,66 @@
+package orm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/golobby/orm/querybuilder"
+)
+
+var mysqlDialect = &querybuilder.Dialect{
+	DriverName:                "mysql",
+	PlaceholderChar:           "?",
+	IncludeIndexInPlaceholder: false,
+}
+
+// TestIsMissingTableErrorDoesNotSwallowRealErrors guards against the
+// bug where tableExists treated every query error as "table does not
+// exist", so AutoMigrate could attempt a bogus CREATE TABLE over a real
+// outage instead of surfacing it.
+func TestIsMissingTableErrorDoesNotSwallowRealErrors(t *testing.T) {
+	if isMissingTableError(sqliteDialect, errors.New("no such table: posts")) != true {
+		t.Error("sqlite: expected a \"no such table\" error to be treated as missing")
+	}
+	if isMissingTableError(sqliteDialect, errors.New("database is locked")) {
+		t.Error("sqlite: a real error must not be treated as missing")
+	}
+	if isMissingTableError(pgDialect, errors.New("connection reset by peer")) {
+		t.Error("a connection failure must not be treated as a missing table")
+	}
+	if !isMissingTableError(pgDialect, errors.New(`relation "posts" does not exist`)) {
+		t.Error("postgres's actual missing-relation error must be treated as missing")
+	}
+}
+
+func TestSqlColumnTypeDialectBranching(t *testing.T) {
+	boolType := reflect.TypeOf(true)
+	floatType := reflect.TypeOf(float64(0))
+
+	if got, want := sqlColumnType(pgDialect, boolType), "BOOLEAN"; got != want {
+		t.Errorf("postgres bool = %q, want %q", got, want)
+	}
+	if got, want := sqlColumnType(mysqlDialect, boolType), "TINYINT(1)"; got != want {
+		t.Errorf("mysql bool = %q, want %q", got, want)
+	}
+	if got, want := sqlColumnType(mysqlDialect, floatType), "DOUBLE"; got != want {
+		t.Errorf("mysql float = %q, want %q", got, want)
+	}
+	if got, want := sqlColumnType(sqliteDialect, floatType), "REAL"; got != want {
+		t.Errorf("sqlite float = %q, want %q", got, want)
+	}
+}
+
+func TestCreateIndexSQL(t *testing.T) {
+	got := createIndexSQL("posts", Index{Name: "idx_posts_author", Columns: []string{"author_id"}})
+	want := "CREATE INDEX IF NOT EXISTS idx_posts_author ON posts (author_id)"
+	if got != want {
+		t.Errorf("createIndexSQL = %q, want %q", got, want)
+	}
+
+	got = createIndexSQL("posts", Index{Name: "idx_posts_slug", Columns: []string{"slug"}, Unique: true})
+	want = "CREATE UNIQUE INDEX IF NOT EXISTS idx_posts_slug ON posts (slug)"
+	if got != want {
+		t.Errorf("createIndexSQL = %q, want %q", got, want)
+	}
+}
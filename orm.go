@@ -5,7 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"reflect"
-	"strings"
+	"sync"
 
 	"github.com/jedib0t/go-pretty/table"
 
@@ -29,6 +29,28 @@ type Connection struct {
 	Dialect    *querybuilder.Dialect
 	Connection *sql.DB
 	Schemas    map[string]*schema
+	// callbacksMu guards callbacks: runCallbacks lazily creates chains
+	// and appends the entity slot on every CRUD call (not just at
+	// registration), so concurrent callers sharing a Connection must not
+	// race on the map or a chain's entries slice.
+	callbacksMu sync.Mutex
+	callbacks   map[string]*callbackChain
+	// entityTypes maps a table name to the concrete Go struct type
+	// registered for it, so Preload can allocate the right type for a
+	// relation it only knows by table name at runtime.
+	entityTypes map[string]reflect.Type
+	// cacher is the optional second-level query cache consulted by the
+	// read paths and invalidated by the write paths; nil means caching
+	// is off, which is the default. Set it with SetDefaultCacher.
+	cacher Cacher
+}
+
+// SetDefaultCacher wires a Cacher into every read/write path on d; pass
+// nil to disable caching again. Entities still opt in individually by
+// implementing CacheableEntity, matching xorm's SetDefaultCacher
+// ergonomics where the cacher is global but cacheable tables are not.
+func (d *Connection) SetDefaultCacher(c Cacher) {
+	d.cacher = c
 }
 
 func (c *Connection) Schematic() {
@@ -78,14 +100,14 @@ type ConnectionConfig struct {
 	Entities         []Entity
 }
 
-func initTableName(e Entity) string {
+func initTableName(e Entity) (string, error) {
 	configurator := newEntityConfigurator()
 	e.ConfigureEntity(configurator)
 
 	if configurator.table == "" {
-		panic("Table name is mandatory for entities")
+		return "", fmt.Errorf("orm: table name is mandatory for entity %T", e)
 	}
-	return configurator.table
+	return configurator.table, nil
 }
 
 func Initialize(confs ...ConnectionConfig) error {
@@ -106,28 +128,47 @@ func Initialize(confs ...ConnectionConfig) error {
 				return err
 			}
 		}
-		initialize(conf.Name, dialect, db, conf.Entities)
+		if _, err := initialize(conf.Name, dialect, db, conf.Entities); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func initialize(name string, dialect *querybuilder.Dialect, db *sql.DB, entities []Entity) *Connection {
+func initialize(name string, dialect *querybuilder.Dialect, db *sql.DB, entities []Entity) (*Connection, error) {
 	schemas := map[string]*schema{}
+	entityTypes := map[string]reflect.Type{}
 	for _, entity := range entities {
 		md := schemaOf(entity)
 		if md.dialect == nil {
 			md.dialect = dialect
 		}
-		schemas[fmt.Sprintf("%s", initTableName(entity))] = md
+		table, err := initTableName(entity)
+		if err != nil {
+			return nil, err
+		}
+		schemas[table] = md
+		entityTypes[table] = entityStructType(entity)
 	}
 	s := &Connection{
-		Name:       name,
-		Connection: db,
-		Schemas:    schemas,
-		Dialect:    dialect,
+		Name:        name,
+		Connection:  db,
+		Schemas:     schemas,
+		Dialect:     dialect,
+		entityTypes: entityTypes,
 	}
 	globalORM[fmt.Sprintf("%s", name)] = s
-	return s
+	return s, nil
+}
+
+// entityStructType returns the underlying struct type of e, unwrapping
+// the pointer receiver entities are conventionally registered with.
+func entityStructType(e Entity) reflect.Type {
+	t := reflect.TypeOf(e)
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
 }
 
 type Entity interface {
@@ -154,6 +195,17 @@ func getDialect(driver string) (*querybuilder.Dialect, error) {
 
 // Insert given Entity
 func Insert(obj Entity) error {
+	return insertVia(context.Background(), getConnectionFor(obj), obj)
+}
+
+// insertVia is Insert's implementation, run against ex so Tx.Insert can
+// share it instead of duplicating the statement-building logic.
+func insertVia(ctx context.Context, ex executor, obj Entity) error {
+	conn := getConnectionFor(obj)
+	if err := runCallbacks(ctx, conn, EventBeforeInsert, obj); err != nil {
+		return err
+	}
+
 	cols := getSchemaFor(obj).Columns(false)
 	values := genericValuesOf(obj, false)
 	var phs []string
@@ -169,7 +221,7 @@ func Insert(obj Entity) error {
 		Values(phs...).
 		WithArgs(values...).Build()
 
-	res, err := getSchemaFor(obj).getSQLDB().Exec(q, args...)
+	res, err := ex.ExecContext(ctx, q, args...)
 	if err != nil {
 		return err
 	}
@@ -178,15 +230,28 @@ func Insert(obj Entity) error {
 		return err
 	}
 	genericSetPkValue(obj, id)
-	return nil
+	invalidateQueries(getSchemaFor(obj))
+
+	return runCallbacks(ctx, conn, EventAfterInsert, obj)
 }
 
+// InsertAll batch-inserts objs in one statement; they must all belong to
+// the same table.
 func InsertAll(objs ...Entity) error {
-	if len(objs) == 1 {
-		return Insert(objs[0])
-	} else if len(objs) == 0 {
+	if len(objs) == 0 {
 		return nil
 	}
+	return insertAllVia(context.Background(), getConnectionFor(objs[0]), objs...)
+}
+
+// insertAllVia is InsertAll's implementation, run against ex so
+// Tx.InsertAll can share it instead of duplicating the statement-
+// building logic.
+func insertAllVia(ctx context.Context, ex executor, objs ...Entity) error {
+	if len(objs) == 1 {
+		return insertVia(ctx, ex, objs[0])
+	}
+
 	var lastTable string
 	for _, obj := range objs {
 		s := getSchemaFor(obj)
@@ -197,6 +262,9 @@ func InsertAll(objs ...Entity) error {
 				return fmt.Errorf("cannot batch insert for two different tables: %s and %s", s.Table, lastTable)
 			}
 		}
+		if err := runCallbacks(ctx, getConnectionFor(obj), EventBeforeInsert, obj); err != nil {
+			return err
+		}
 	}
 
 	cols := getSchemaFor(objs[0]).Columns(false)
@@ -215,18 +283,23 @@ func InsertAll(objs ...Entity) error {
 		Into(cols...)
 	for idx, obj := range objs {
 		qb.Values(phs[idx]...)
-		qb.WithArgs(genericValuesOf(obj, false))
+		qb.WithArgs(genericValuesOf(obj, false)...)
 	}
 
 	q, args := qb.Build()
 
-	_, err := getConnectionFor(objs[0]).Connection.Exec(q, args)
-	if err != nil {
+	if _, err := ex.ExecContext(ctx, q, args...); err != nil {
 		return err
 	}
+	invalidateTable(getSchemaFor(objs[0]))
 
-	return err
+	for _, obj := range objs {
+		if err := runCallbacks(ctx, getConnectionFor(obj), EventAfterInsert, obj); err != nil {
+			return err
+		}
+	}
 
+	return nil
 }
 
 // Save upserts given entity.
@@ -238,33 +311,26 @@ func Save(obj Entity) error {
 	}
 }
 
-// Find finds the Entity you want based on Entity generic type and primary key you passed.
-func Find[T Entity](id interface{}) (T, error) {
-	var q string
-	out := new(T)
-	md := getSchemaFor(*out)
-	var args []interface{}
-	ph := md.dialect.PlaceholderChar
-	if md.dialect.IncludeIndexInPlaceholder {
-		ph = ph + "1"
-	}
-	qb := &querybuilder.Select{}
-	builder := qb.
-		Select(md.Columns(true)...).
-		From(md.Table).
-		Where(querybuilder.WhereHelpers.Equal(md.pkName(), ph)).
-		WithArgs(id)
-
-	q, args = builder.
-		Build()
-
-	err := bindContext[T](context.Background(), out, q, args)
-
-	if err != nil {
-		return *out, err
-	}
+// Find returns a QueryBuilder for the row identified by id. Chain
+// .Preload paths before a terminal .Get(ctx); a row that doesn't exist
+// surfaces as the sql.ErrNoRows Get reports, not a panic.
+func Find[T Entity](id interface{}) *QueryBuilder[T] {
+	md := getSchemaFor(*new(T))
+	qb := newQueryBuilder[T](md.Table).WhereCond(Exact(md.pkName(), id))
+	qb.cacheID = id
+	return qb
+}
 
-	return *out, nil
+// TxFind is Find scoped to tx: its terminal Get/All runs against tx's
+// *sql.Tx instead of the Connection's *sql.DB, so a caller can read back
+// a row it wrote earlier in the same transaction before it's committed.
+// It never consults the second-level cache, since a row read mid-
+// transaction may not match what every other reader currently sees.
+func TxFind[T Entity](tx *Tx, id interface{}) *QueryBuilder[T] {
+	md := getSchemaFor(*new(T))
+	qb := newQueryBuilder[T](md.Table).WhereCond(Exact(md.pkName(), id))
+	qb.ex = tx
+	return qb
 }
 
 func toMap(obj Entity, withPK bool) []keyValue {
@@ -282,58 +348,90 @@ func toMap(obj Entity, withPK bool) []keyValue {
 
 // Update given Entity in database
 func Update(obj Entity) error {
-	ph := getSchemaFor(obj).getDialect().PlaceholderChar
-	if getSchemaFor(obj).getDialect().IncludeIndexInPlaceholder {
-		ph = ph + "1"
+	return updateVia(context.Background(), getConnectionFor(obj), obj)
+}
+
+// updateVia is Update's implementation, run against ex so Tx.Update can
+// share it instead of duplicating the statement-building logic.
+func updateVia(ctx context.Context, ex executor, obj Entity) error {
+	conn := getConnectionFor(obj)
+	if err := runCallbacks(ctx, conn, EventBeforeUpdate, obj); err != nil {
+		return err
 	}
-	counter := 2
+
+	md := getSchemaFor(obj)
+	argIndex := 1
 	kvs := toMap(obj, false)
-	var kvsWithPh []keyValue
-	var args []interface{}
-	whereClause := querybuilder.WhereHelpers.Equal(getSchemaFor(obj).pkName(), ph)
 	query := querybuilder.UpdateStmt().
-		Table(getSchemaFor(obj).getTable()).
-		Where(whereClause)
+		Table(md.getTable())
 	for _, kv := range kvs {
-		thisPh := getSchemaFor(obj).getDialect().PlaceholderChar
-		if getSchemaFor(obj).getDialect().IncludeIndexInPlaceholder {
-			thisPh += fmt.Sprint(counter)
-		}
-		kvsWithPh = append(kvsWithPh, keyValue{Key: kv.Key, Value: thisPh})
+		thisPh := nextPlaceholder(md.dialect, &argIndex)
 		query.Set(kv.Key, thisPh)
 		query.WithArgs(kv.Value)
-		counter++
 	}
-	query.WithArgs(genericGetPKValue(obj))
+	whereSQL, whereArgs := Exact(md.pkName(), genericGetPKValue(obj)).render(md.dialect, &argIndex)
+	query.Where(whereSQL).WithArgs(whereArgs...)
 	q, args := query.Build()
-	_, err := getSchemaFor(obj).getSQLDB().Exec(q, args...)
-	return err
+	_, err := ex.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	invalidateRow(obj)
+	return runCallbacks(ctx, conn, EventAfterUpdate, obj)
 }
 
 // Delete given Entity from database
 func Delete(obj Entity) error {
-	ph := getSchemaFor(obj).getDialect().PlaceholderChar
-	if getSchemaFor(obj).getDialect().IncludeIndexInPlaceholder {
-		ph = ph + "1"
+	return deleteVia(context.Background(), getConnectionFor(obj), obj)
+}
+
+// deleteVia is Delete's implementation, run against ex so Tx.Delete can
+// share it instead of duplicating the statement-building logic.
+func deleteVia(ctx context.Context, ex executor, obj Entity) error {
+	conn := getConnectionFor(obj)
+	if err := runCallbacks(ctx, conn, EventBeforeDelete, obj); err != nil {
+		return err
 	}
-	query := querybuilder.WhereHelpers.Equal(getSchemaFor(obj).pkName(), ph)
+
+	md := getSchemaFor(obj)
+	whereSQL, whereArgs := renderCond(md.dialect, Exact(md.pkName(), genericGetPKValue(obj)))
 	qb := &querybuilder.Delete{}
 	q, args := qb.
-		Table(getSchemaFor(obj).getTable()).
-		Where(query).
-		WithArgs(genericGetPKValue(obj)).
+		Table(md.getTable()).
+		Where(whereSQL).
+		WithArgs(whereArgs...).
 		Build()
-	_, err := getSchemaFor(obj).getSQLDB().Exec(q, args...)
-	return err
+	_, err := ex.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	invalidateRow(obj)
+	return runCallbacks(ctx, conn, EventAfterDelete, obj)
+}
+
+func bindContext[T Entity](ctx context.Context, ex executor, output interface{}, q string, args []interface{}) error {
+	return bindRows(ctx, getSchemaFor(*new(T)), ex, q, args, output)
 }
 
-func bindContext[T Entity](ctx context.Context, output interface{}, q string, args []interface{}) error {
-	outputMD := getSchemaFor(*new(T))
-	rows, err := outputMD.getConnection().Connection.QueryContext(ctx, q, args...)
+// bindRows runs q against ex and binds the result into output (a
+// pointer to an Entity or a slice of them), the same way bindContext
+// does for a statically known T. Preload uses this to bind relation rows
+// whose Go type is only known at runtime via md. Rows read through a Tx
+// skip the second-level cache, since they may not be visible to every
+// other reader yet.
+func bindRows(ctx context.Context, md *schema, ex executor, q string, args []interface{}, output interface{}) error {
+	conn := md.getConnection()
+	rows, err := ex.QueryContext(ctx, q, args...)
 	if err != nil {
 		return err
 	}
-	return outputMD.bind(rows, output)
+	if err := md.bind(rows, output); err != nil {
+		return err
+	}
+	if !txExecutor(ex) {
+		cacheBoundRows(md, output)
+	}
+	return runAfterFind(ctx, conn, output)
 }
 
 type HasManyConfig struct {
@@ -341,48 +439,27 @@ type HasManyConfig struct {
 	PropertyForeignKey string
 }
 
-func HasMany[OUT Entity](owner Entity) ([]OUT, error) {
+// HasMany returns a QueryBuilder for OUT rows belonging to owner. Chain
+// .Where/.OrderBy/.Limit/.Offset before a terminal .All(ctx) to further
+// filter the relation; a misconfigured relation is reported by .All(ctx)
+// rather than panicking.
+func HasMany[OUT Entity](owner Entity) *QueryBuilder[OUT] {
 	outSchema := getSchemaFor(*new(OUT))
 	c, ok := getSchemaFor(owner).relations[outSchema.Table].(HasManyConfig)
 	if !ok {
-		return nil, fmt.Errorf("wrong config passed for HasMany")
+		return newQueryBuilder[OUT](outSchema.Table).fail(fmt.Errorf("wrong config passed for HasMany"))
 	}
 
-	property := schemaOf(*(new(OUT)))
-	var out []OUT
-
 	//settings default config Values
 	if c.PropertyTable == "" {
-		c.PropertyTable = property.Table
+		c.PropertyTable = outSchema.Table
 	}
 	if c.PropertyForeignKey == "" {
 		c.PropertyForeignKey = pluralize.NewClient().Singular(getSchemaFor(owner).getTable()) + "_id"
 	}
 
-	ph := getSchemaFor(owner).getDialect().PlaceholderChar
-	if getSchemaFor(owner).getDialect().IncludeIndexInPlaceholder {
-		ph = ph + fmt.Sprint(1)
-	}
-	var q string
-	var args []interface{}
-	qb := &querybuilder.Select{}
-	q, args = qb.
-		From(c.PropertyTable).
-		Where(querybuilder.WhereHelpers.Equal(c.PropertyForeignKey, ph)).
-		WithArgs(genericGetPKValue(owner)).
-		Build()
-
-	if q == "" {
-		return nil, fmt.Errorf("cannot build the query")
-	}
-
-	err := bindContext[OUT](context.Background(), &out, q, args)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return out, nil
+	return newQueryBuilder[OUT](c.PropertyTable).
+		WhereCond(Exact(c.PropertyForeignKey, genericGetPKValue(owner)))
 }
 
 type HasOneConfig struct {
@@ -390,12 +467,14 @@ type HasOneConfig struct {
 	PropertyForeignKey string
 }
 
-func HasOne[PROPERTY Entity](owner Entity) (PROPERTY, error) {
-	out := new(PROPERTY)
+// HasOne returns a QueryBuilder for the PROPERTY row belonging to owner.
+// Chain further filters before a terminal .Get(ctx); a misconfigured
+// relation is reported there instead of panicking.
+func HasOne[PROPERTY Entity](owner Entity) *QueryBuilder[PROPERTY] {
 	property := getSchemaFor(*new(PROPERTY))
 	c, ok := getSchemaFor(owner).relations[property.Table].(HasOneConfig)
 	if !ok {
-		return *new(PROPERTY), fmt.Errorf("wrong config passed for HasOne")
+		return newQueryBuilder[PROPERTY](property.Table).fail(fmt.Errorf("wrong config passed for HasOne"))
 	}
 	//settings default config Values
 	if c.PropertyTable == "" {
@@ -405,26 +484,8 @@ func HasOne[PROPERTY Entity](owner Entity) (PROPERTY, error) {
 		c.PropertyForeignKey = pluralize.NewClient().Singular(getSchemaFor(owner).Table) + "_id"
 	}
 
-	ph := property.dialect.PlaceholderChar
-	if property.dialect.IncludeIndexInPlaceholder {
-		ph = ph + fmt.Sprint(1)
-	}
-	var q string
-	var args []interface{}
-	qb := &querybuilder.Select{}
-	q, args = qb.
-		From(c.PropertyTable).
-		Where(querybuilder.WhereHelpers.Equal(c.PropertyForeignKey, ph)).
-		WithArgs(genericGetPKValue(owner)).
-		Build()
-
-	if q == "" {
-		return *out, fmt.Errorf("cannot build the query")
-	}
-
-	err := bindContext[PROPERTY](context.Background(), out, q, args)
-
-	return *out, err
+	return newQueryBuilder[PROPERTY](c.PropertyTable).
+		WhereCond(Exact(c.PropertyForeignKey, genericGetPKValue(owner)))
 }
 
 type BelongsToConfig struct {
@@ -433,12 +494,14 @@ type BelongsToConfig struct {
 	ForeignColumnName string
 }
 
-func BelongsTo[OWNER Entity](property Entity) (OWNER, error) {
-	out := new(OWNER)
+// BelongsTo returns a QueryBuilder for the OWNER row property belongs
+// to. Chain further filters before a terminal .Get(ctx); a
+// misconfigured relation is reported there instead of panicking.
+func BelongsTo[OWNER Entity](property Entity) *QueryBuilder[OWNER] {
 	owner := getSchemaFor(*new(OWNER))
 	c, ok := getSchemaFor(property).relations[owner.Table].(BelongsToConfig)
 	if !ok {
-		return *new(OWNER), fmt.Errorf("wrong config passed for BelongsTo")
+		return newQueryBuilder[OWNER](owner.Table).fail(fmt.Errorf("wrong config passed for BelongsTo"))
 	}
 	if c.OwnerTable == "" {
 		c.OwnerTable = owner.Table
@@ -450,10 +513,6 @@ func BelongsTo[OWNER Entity](property Entity) (OWNER, error) {
 		c.ForeignColumnName = "id"
 	}
 
-	ph := owner.getDialect().PlaceholderChar
-	if owner.getDialect().IncludeIndexInPlaceholder {
-		ph = ph + fmt.Sprint(1)
-	}
 	ownerIDidx := 0
 	for idx, field := range owner.fields {
 		if field.Name == c.LocalForeignKey {
@@ -462,14 +521,9 @@ func BelongsTo[OWNER Entity](property Entity) (OWNER, error) {
 	}
 
 	ownerID := genericValuesOf(property, true)[ownerIDidx]
-	qb := &querybuilder.Select{}
-	q, args := qb.
-		From(c.OwnerTable).
-		Where(querybuilder.WhereHelpers.Equal(c.ForeignColumnName, ph)).
-		WithArgs(ownerID).Build()
 
-	err := bindContext[OWNER](context.Background(), out, q, args)
-	return *out, err
+	return newQueryBuilder[OWNER](c.OwnerTable).
+		WhereCond(Exact(c.ForeignColumnName, ownerID))
 }
 
 type BelongsToManyConfig struct {
@@ -480,52 +534,37 @@ type BelongsToManyConfig struct {
 	ForeignLookupColumn    string
 }
 
-//BelongsToMany
-func BelongsToMany[OWNER Entity](property Entity) ([]OWNER, error) {
-	out := new(OWNER)
-	c, ok := getSchemaFor(property).relations[getSchemaFor(*out).Table].(BelongsToManyConfig)
+// BelongsToMany returns a QueryBuilder for the OWNER rows linked to
+// property through the configured intermediate table. Chain further
+// filters before a terminal .All(ctx); a misconfigured relation is
+// reported there instead of panicking.
+func BelongsToMany[OWNER Entity](property Entity) *QueryBuilder[OWNER] {
+	ownerSchema := getSchemaFor(*new(OWNER))
+	c, ok := getSchemaFor(property).relations[ownerSchema.Table].(BelongsToManyConfig)
 	if !ok {
-		return nil, fmt.Errorf("wrong config passed for HasMany")
+		return newQueryBuilder[OWNER](ownerSchema.Table).fail(fmt.Errorf("wrong config passed for BelongsToMany"))
 	}
 	if c.ForeignLookupColumn == "" {
-		c.ForeignLookupColumn = getSchemaFor(*new(OWNER)).pkName()
+		c.ForeignLookupColumn = ownerSchema.pkName()
 	}
 	if c.ForeignTable == "" {
-		c.ForeignTable = getSchemaFor(*new(OWNER)).Table
+		c.ForeignTable = ownerSchema.Table
 	}
 	if c.IntermediateTable == "" {
-		return nil, fmt.Errorf("cannot infer intermediate table yet")
+		return newQueryBuilder[OWNER](ownerSchema.Table).fail(fmt.Errorf("cannot infer intermediate table yet"))
 	}
 	if c.IntermediatePropertyID == "" {
 		c.IntermediatePropertyID = pluralize.NewClient().Singular(getSchemaFor(property).Table) + "_id"
 	}
 	if c.IntermediateOwnerID == "" {
-		c.IntermediateOwnerID = pluralize.NewClient().Singular(getSchemaFor(*out).Table) + "_id"
+		c.IntermediateOwnerID = pluralize.NewClient().Singular(ownerSchema.Table) + "_id"
 	}
 
-	q := fmt.Sprintf(`select %s from %s where %s IN (select %s from %s where %s = ?)`,
-		strings.Join(getSchemaFor(*out).Columns(true), ","),
-		getSchemaFor(*out).Table,
-		c.ForeignLookupColumn,
-		c.IntermediateOwnerID,
-		c.IntermediateTable,
-		c.IntermediatePropertyID,
-	)
-
-	args := []interface{}{genericGetPKValue(property)}
-
-	rows, err := getSchemaFor(*out).getSQLDB().Query(q, args...)
-
-	if err != nil {
-		return nil, err
-	}
-	var output []OWNER
-	err = getSchemaFor(*out).bind(rows, &output)
-	if err != nil {
-		return nil, err
-	}
+	subquery := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %%s",
+		c.IntermediateOwnerID, c.IntermediateTable, c.IntermediatePropertyID)
 
-	return output, nil
+	return newQueryBuilder[OWNER](c.ForeignTable).
+		WhereCond(InSubquery(c.ForeignLookupColumn, subquery, genericGetPKValue(property)))
 }
 
 type RelationType int
@@ -543,47 +582,77 @@ func Add(to Entity, items ...Entity) error {
 }
 
 func Query[OUTPUT Entity](stmt *querybuilder.Select) ([]OUTPUT, error) {
-	o := new(OUTPUT)
-	rows, err := getSchemaFor(*o).getSQLDB().Query(stmt.Build())
+	md := getSchemaFor(*new(OUTPUT))
+	return queryVia[OUTPUT](context.Background(), md.getConnection(), stmt)
+}
+
+// TxQuery is Query scoped to tx; see TxFind for why it bypasses the
+// second-level cache.
+func TxQuery[OUTPUT Entity](tx *Tx, stmt *querybuilder.Select) ([]OUTPUT, error) {
+	return queryVia[OUTPUT](context.Background(), tx, stmt)
+}
+
+func queryVia[OUTPUT Entity](ctx context.Context, ex executor, stmt *querybuilder.Select) ([]OUTPUT, error) {
+	md := getSchemaFor(*new(OUTPUT))
+	q, args := stmt.Build()
+
+	if !txExecutor(ex) {
+		if cached, ok := queryFromCache[OUTPUT](md, q, args); ok {
+			return cached, nil
+		}
+	}
+
+	rows, err := ex.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
 	var output []OUTPUT
-	err = getSchemaFor(*o).bind(rows, output)
-	if err != nil {
+	if err := md.bind(rows, &output); err != nil {
+		return nil, err
+	}
+	if !txExecutor(ex) {
+		cacheQueryResult(md, q, args, output)
+	}
+	if err := runAfterFind(ctx, md.getConnection(), &output); err != nil {
 		return nil, err
 	}
 	return output, nil
 }
 
 func Exec[E Entity](stmt querybuilder.SQL) (int64, int64, error) {
-	e := new(E)
-
-	res, err := getSchemaFor(*e).getSQLDB().Exec(stmt.Build())
-	if err != nil {
-		return 0, 0, err
-	}
+	md := getSchemaFor(*new(E))
+	q, args := stmt.Build()
+	return execRawVia[E](context.Background(), md.getConnection(), q, args...)
+}
 
-	id, err := res.LastInsertId()
-	if err != nil {
-		return 0, 0, err
-	}
+// TxExec is Exec scoped to tx.
+func TxExec[E Entity](tx *Tx, stmt querybuilder.SQL) (int64, int64, error) {
+	q, args := stmt.Build()
+	return execRawVia[E](context.Background(), tx, q, args...)
+}
 
-	affected, err := res.RowsAffected()
-	if err != nil {
-		return 0, 0, err
-	}
+// ExecRaw runs q for its side effects. Because an arbitrary statement
+// can touch rows the cache has no way to identify individually, it
+// busts every cache entry for E's table rather than trying to guess
+// which ones it affected.
+func ExecRaw[E Entity](q string, args ...interface{}) (int64, int64, error) {
+	md := getSchemaFor(*new(E))
+	return execRawVia[E](context.Background(), md.getConnection(), q, args...)
+}
 
-	return id, affected, nil
+// TxExecRaw is ExecRaw scoped to tx.
+func TxExecRaw[E Entity](tx *Tx, q string, args ...interface{}) (int64, int64, error) {
+	return execRawVia[E](context.Background(), tx, q, args...)
 }
 
-func ExecRaw[E Entity](q string, args ...interface{}) (int64, int64, error) {
-	e := new(E)
+func execRawVia[E Entity](ctx context.Context, ex executor, q string, args ...interface{}) (int64, int64, error) {
+	md := getSchemaFor(*new(E))
 
-	res, err := getSchemaFor(*e).getSQLDB().Exec(q, args...)
+	res, err := ex.ExecContext(ctx, q, args...)
 	if err != nil {
 		return 0, 0, err
 	}
+	invalidateTable(md)
 
 	id, err := res.LastInsertId()
 	if err != nil {
@@ -599,14 +668,37 @@ func ExecRaw[E Entity](q string, args ...interface{}) (int64, int64, error) {
 }
 
 func QueryRaw[OUTPUT Entity](q string, args ...interface{}) ([]OUTPUT, error) {
-	o := new(OUTPUT)
-	rows, err := getSchemaFor(*o).getSQLDB().Query(q, args...)
+	md := getSchemaFor(*new(OUTPUT))
+	return queryRawVia[OUTPUT](context.Background(), md.getConnection(), q, args...)
+}
+
+// TxQueryRaw is QueryRaw scoped to tx; see TxFind for why it bypasses
+// the second-level cache.
+func TxQueryRaw[OUTPUT Entity](tx *Tx, q string, args ...interface{}) ([]OUTPUT, error) {
+	return queryRawVia[OUTPUT](context.Background(), tx, q, args...)
+}
+
+func queryRawVia[OUTPUT Entity](ctx context.Context, ex executor, q string, args ...interface{}) ([]OUTPUT, error) {
+	md := getSchemaFor(*new(OUTPUT))
+
+	if !txExecutor(ex) {
+		if cached, ok := queryFromCache[OUTPUT](md, q, args); ok {
+			return cached, nil
+		}
+	}
+
+	rows, err := ex.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
 	var output []OUTPUT
-	err = getSchemaFor(*o).bind(rows, output)
-	if err != nil {
+	if err := md.bind(rows, &output); err != nil {
+		return nil, err
+	}
+	if !txExecutor(ex) {
+		cacheQueryResult(md, q, args, output)
+	}
+	if err := runAfterFind(ctx, md.getConnection(), &output); err != nil {
 		return nil, err
 	}
 	return output, nil
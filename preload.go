@@ -0,0 +1,323 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gertd/go-pluralize"
+	"github.com/golobby/orm/querybuilder"
+)
+
+// preloadInto eager-loads paths onto owners, an addressable slice of
+// whatever Entity struct md describes. For each path it groups owners
+// by primary key, issues a single "fk IN (?, ?, ...)" query per
+// relation (two for BelongsToMany: one against the intermediate table,
+// one for the related rows) -- honoring md's dialect placeholder rules via Cond/In, the
+// same way a hand-written relation lookup would -- and buckets the
+// result back onto the matching struct field, the way GORM's
+// callback_query_preload.go does. A dotted continuation such as
+// "Comments.Author" recurses with the fetched rows as the new owners.
+func preloadInto(ctx context.Context, md *schema, owners reflect.Value, paths []string) error {
+	if owners.Len() == 0 {
+		return nil
+	}
+	conn := md.getConnection()
+
+	var order []string
+	nested := map[string][]string{}
+	for _, p := range paths {
+		head, rest, hasRest := strings.Cut(p, ".")
+		if _, seen := nested[head]; !seen {
+			order = append(order, head)
+			nested[head] = nil
+		}
+		if hasRest {
+			nested[head] = append(nested[head], rest)
+		}
+	}
+
+	for _, head := range order {
+		table := strings.ToLower(head)
+		cfg, ok := md.relations[table]
+		if !ok {
+			return fmt.Errorf("orm: preload: %s has no relation %q", md.Table, head)
+		}
+		childMD := conn.getSchema(table)
+		if childMD == nil {
+			return fmt.Errorf("orm: preload: no schema registered for table %q", table)
+		}
+		childType, ok := conn.entityTypes[table]
+		if !ok {
+			return fmt.Errorf("orm: preload: no entity type registered for table %q", table)
+		}
+
+		fetched, err := loadRelation(ctx, md, owners, head, cfg, childMD, childType)
+		if err != nil {
+			return fmt.Errorf("orm: preload %q: %w", head, err)
+		}
+
+		if rest := nested[head]; len(rest) > 0 {
+			if err := preloadInto(ctx, childMD, fetched, rest); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadRelation dispatches to the loader matching cfg's concrete
+// relation kind and returns every fetched child row, so the caller can
+// recurse into a dotted continuation with them as the new owners.
+func loadRelation(ctx context.Context, md *schema, owners reflect.Value, field string, cfg interface{}, childMD *schema, childType reflect.Type) (reflect.Value, error) {
+	switch c := cfg.(type) {
+	case HasManyConfig:
+		return loadHasMany(ctx, md, owners, field, c, childMD, childType)
+	case HasOneConfig:
+		return loadHasOne(ctx, md, owners, field, c, childMD, childType)
+	case BelongsToConfig:
+		return loadBelongsTo(ctx, md, owners, field, c, childMD, childType)
+	case BelongsToManyConfig:
+		return loadBelongsToMany(ctx, md, owners, field, c, childMD, childType)
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported relation config %T", cfg)
+	}
+}
+
+func loadHasMany(ctx context.Context, md *schema, owners reflect.Value, field string, c HasManyConfig, childMD *schema, childType reflect.Type) (reflect.Value, error) {
+	if c.PropertyTable == "" {
+		c.PropertyTable = childMD.Table
+	}
+	if c.PropertyForeignKey == "" {
+		c.PropertyForeignKey = pluralize.NewClient().Singular(md.getTable()) + "_id"
+	}
+
+	pks, byPK := ownerPKIndex(owners)
+	rows, err := fetchByIn(ctx, childMD, childType, c.PropertyForeignKey, pks)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	for i := 0; i < rows.Len(); i++ {
+		child := rows.Index(i)
+		fk := columnValue(childMD, entityAt(child), c.PropertyForeignKey)
+		for _, ownerIdx := range byPK[fk] {
+			appendRelation(owners.Index(ownerIdx).FieldByName(field), child)
+		}
+	}
+	return rows, nil
+}
+
+func loadHasOne(ctx context.Context, md *schema, owners reflect.Value, field string, c HasOneConfig, childMD *schema, childType reflect.Type) (reflect.Value, error) {
+	if c.PropertyTable == "" {
+		c.PropertyTable = childMD.Table
+	}
+	if c.PropertyForeignKey == "" {
+		c.PropertyForeignKey = pluralize.NewClient().Singular(md.getTable()) + "_id"
+	}
+
+	pks, byPK := ownerPKIndex(owners)
+	rows, err := fetchByIn(ctx, childMD, childType, c.PropertyForeignKey, pks)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	for i := 0; i < rows.Len(); i++ {
+		child := rows.Index(i)
+		fk := columnValue(childMD, entityAt(child), c.PropertyForeignKey)
+		for _, ownerIdx := range byPK[fk] {
+			setRelation(owners.Index(ownerIdx).FieldByName(field), child)
+		}
+	}
+	return rows, nil
+}
+
+func loadBelongsTo(ctx context.Context, md *schema, owners reflect.Value, field string, c BelongsToConfig, childMD *schema, childType reflect.Type) (reflect.Value, error) {
+	if c.OwnerTable == "" {
+		c.OwnerTable = childMD.Table
+	}
+	if c.LocalForeignKey == "" {
+		c.LocalForeignKey = pluralize.NewClient().Singular(childMD.Table) + "_id"
+	}
+	if c.ForeignColumnName == "" {
+		c.ForeignColumnName = "id"
+	}
+
+	var fks []interface{}
+	byFK := map[interface{}][]int{}
+	for i := 0; i < owners.Len(); i++ {
+		fk := columnValue(md, entityAt(owners.Index(i)), c.LocalForeignKey)
+		if _, seen := byFK[fk]; !seen {
+			fks = append(fks, fk)
+		}
+		byFK[fk] = append(byFK[fk], i)
+	}
+
+	rows, err := fetchByIn(ctx, childMD, childType, c.ForeignColumnName, fks)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	for i := 0; i < rows.Len(); i++ {
+		child := rows.Index(i)
+		ownerKey := columnValue(childMD, entityAt(child), c.ForeignColumnName)
+		for _, ownerIdx := range byFK[ownerKey] {
+			setRelation(owners.Index(ownerIdx).FieldByName(field), child)
+		}
+	}
+	return rows, nil
+}
+
+func loadBelongsToMany(ctx context.Context, md *schema, owners reflect.Value, field string, c BelongsToManyConfig, childMD *schema, childType reflect.Type) (reflect.Value, error) {
+	if c.ForeignLookupColumn == "" {
+		c.ForeignLookupColumn = childMD.pkName()
+	}
+	if c.ForeignTable == "" {
+		c.ForeignTable = childMD.Table
+	}
+	if c.IntermediatePropertyID == "" {
+		c.IntermediatePropertyID = pluralize.NewClient().Singular(md.Table) + "_id"
+	}
+	if c.IntermediateOwnerID == "" {
+		c.IntermediateOwnerID = pluralize.NewClient().Singular(childMD.Table) + "_id"
+	}
+	if c.IntermediateTable == "" {
+		return reflect.Value{}, fmt.Errorf("cannot infer intermediate table for %q", field)
+	}
+
+	pks, byPK := ownerPKIndex(owners)
+
+	whereSQL, whereArgs := renderCond(md.dialect, In(c.IntermediatePropertyID, pks...))
+	q := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s", c.IntermediatePropertyID, c.IntermediateOwnerID, c.IntermediateTable, whereSQL)
+	rows, err := md.getConnection().Connection.QueryContext(ctx, q, whereArgs...)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	defer rows.Close()
+
+	ownerIDsByPropertyPK := map[interface{}][]interface{}{}
+	var childPKs []interface{}
+	seen := map[interface{}]bool{}
+	for rows.Next() {
+		var propertyPK, ownerPK interface{}
+		if err := rows.Scan(&propertyPK, &ownerPK); err != nil {
+			return reflect.Value{}, err
+		}
+		ownerIDsByPropertyPK[propertyPK] = append(ownerIDsByPropertyPK[propertyPK], ownerPK)
+		if !seen[ownerPK] {
+			seen[ownerPK] = true
+			childPKs = append(childPKs, ownerPK)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return reflect.Value{}, err
+	}
+
+	children, err := fetchByIn(ctx, childMD, childType, c.ForeignLookupColumn, childPKs)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	childByPK := map[interface{}]reflect.Value{}
+	for i := 0; i < children.Len(); i++ {
+		child := children.Index(i)
+		childByPK[columnValue(childMD, entityAt(child), c.ForeignLookupColumn)] = child
+	}
+
+	for propertyPK, ownerIdxs := range byPK {
+		for _, ownerID := range ownerIDsByPropertyPK[propertyPK] {
+			child, ok := childByPK[ownerID]
+			if !ok {
+				continue
+			}
+			for _, ownerIdx := range ownerIdxs {
+				appendRelation(owners.Index(ownerIdx).FieldByName(field), child)
+			}
+		}
+	}
+	return children, nil
+}
+
+// fetchByIn issues "SELECT <cols> FROM <childMD.Table> WHERE column IN
+// (?, ?, ...)" against childMD's dialect and binds the rows into a
+// freshly allocated []childType, the Go type Preload resolved the
+// relation's table to at Initialize time.
+func fetchByIn(ctx context.Context, childMD *schema, childType reflect.Type, column string, values []interface{}) (reflect.Value, error) {
+	whereSQL, whereArgs := renderCond(childMD.dialect, In(column, values...))
+	qb := &querybuilder.Select{}
+	q, args := qb.
+		Select(childMD.Columns(true)...).
+		From(childMD.Table).
+		Where(whereSQL).
+		WithArgs(whereArgs...).
+		Build()
+
+	outPtr := reflect.New(reflect.SliceOf(childType))
+	if err := bindRows(ctx, childMD, childMD.getConnection(), q, args, outPtr.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return outPtr.Elem(), nil
+}
+
+// ownerPKIndex groups owners' indexes by primary key value, so every
+// row sharing a PK (there should only ever be one) gets the same
+// eager-loaded children.
+func ownerPKIndex(owners reflect.Value) ([]interface{}, map[interface{}][]int) {
+	pks := make([]interface{}, owners.Len())
+	byPK := map[interface{}][]int{}
+	for i := 0; i < owners.Len(); i++ {
+		pk := genericGetPKValue(entityAt(owners.Index(i)))
+		pks[i] = pk
+		byPK[pk] = append(byPK[pk], i)
+	}
+	return pks, byPK
+}
+
+// columnValue reads column off obj using the same fields-index lookup
+// BelongsTo already relies on to resolve a foreign key generically.
+func columnValue(sch *schema, obj Entity, column string) interface{} {
+	vals := genericValuesOf(obj, true)
+	for idx, f := range sch.fields {
+		if f.Name == column {
+			return vals[idx]
+		}
+	}
+	return nil
+}
+
+// appendRelation appends child onto a HasMany/BelongsToMany struct
+// field, converting between value and pointer element types as needed.
+func appendRelation(field reflect.Value, child reflect.Value) {
+	if !field.IsValid() || field.Kind() != reflect.Slice {
+		return
+	}
+	field.Set(reflect.Append(field, coerceTo(child, field.Type().Elem())))
+}
+
+// setRelation assigns child onto a HasOne/BelongsTo struct field,
+// converting between value and pointer types as needed.
+func setRelation(field reflect.Value, child reflect.Value) {
+	if !field.IsValid() {
+		return
+	}
+	field.Set(coerceTo(child, field.Type()))
+}
+
+// coerceTo adapts v to target when they differ only by a pointer
+// indirection, since a relation field may be declared as either the
+// struct itself or a pointer to it.
+func coerceTo(v reflect.Value, target reflect.Type) reflect.Value {
+	if v.Type() == target {
+		return v
+	}
+	if target.Kind() == reflect.Ptr && v.Type() == target.Elem() {
+		p := reflect.New(target.Elem())
+		p.Elem().Set(v)
+		return p
+	}
+	if v.Kind() == reflect.Ptr && v.Type().Elem() == target {
+		return v.Elem()
+	}
+	return v
+}
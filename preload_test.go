@@ -0,0 +1,57 @@
+package orm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCoerceToPointerIndirection(t *testing.T) {
+	type Author struct{ Name string }
+
+	v := reflect.ValueOf(Author{Name: "Ada"})
+
+	asPtr := coerceTo(v, reflect.TypeOf(&Author{}))
+	if asPtr.Kind() != reflect.Ptr || asPtr.Elem().Interface().(Author).Name != "Ada" {
+		t.Fatalf("coerceTo value->pointer = %#v", asPtr)
+	}
+
+	asValue := coerceTo(asPtr, reflect.TypeOf(Author{}))
+	if asValue.Kind() != reflect.Struct || asValue.Interface().(Author).Name != "Ada" {
+		t.Fatalf("coerceTo pointer->value = %#v", asValue)
+	}
+
+	same := coerceTo(v, reflect.TypeOf(Author{}))
+	if same.Interface().(Author).Name != "Ada" {
+		t.Fatalf("coerceTo identical types = %#v", same)
+	}
+}
+
+func TestAppendRelationAppendsCoercedChild(t *testing.T) {
+	type Comment struct{ Body string }
+	type Post struct{ Comments []*Comment }
+
+	owner := reflect.ValueOf(&Post{}).Elem()
+	child := reflect.ValueOf(Comment{Body: "hi"})
+
+	appendRelation(owner.FieldByName("Comments"), child)
+
+	got := owner.FieldByName("Comments").Interface().([]*Comment)
+	if len(got) != 1 || got[0].Body != "hi" {
+		t.Fatalf("Comments = %#v, want one comment with Body \"hi\"", got)
+	}
+}
+
+func TestSetRelationAssignsCoercedChild(t *testing.T) {
+	type Author struct{ Name string }
+	type Post struct{ Author *Author }
+
+	owner := reflect.ValueOf(&Post{}).Elem()
+	child := reflect.ValueOf(Author{Name: "Ada"})
+
+	setRelation(owner.FieldByName("Author"), child)
+
+	got := owner.FieldByName("Author").Interface().(*Author)
+	if got == nil || got.Name != "Ada" {
+		t.Fatalf("Author = %#v, want &Author{Name: \"Ada\"}", got)
+	}
+}
@@ -0,0 +1,313 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/golobby/sql/builder"
+)
+
+type joinSpec struct {
+	kind  string
+	table string
+	on    string
+}
+
+// QueryBuilder is a fluent, error-deferring builder over a single
+// relation query. Every chain method is a no-op once an error has been
+// recorded, so misuse (an unconfigured relation, an empty table name)
+// never panics: it surfaces from the terminal Get, All or Exec call
+// instead, exactly like database/sql errors do.
+type QueryBuilder[T Entity] struct {
+	err        error
+	table      string
+	cols       []string
+	wheres     []string
+	orderBy    []string
+	joins      []joinSpec
+	limit      int
+	limited    bool
+	offset     int
+	offsetSet  bool
+	args       []interface{}
+	// phIndex is the running placeholder counter WhereCond threads
+	// through every Cond it renders, so two WhereCond calls chained on
+	// the same builder keep numbering "$1", "$2", ... across the whole
+	// statement instead of each restarting at "$1" and colliding.
+	phIndex    int
+	preloads   []string
+	preloadCtx context.Context
+	// cacheID is set only by Find, which is the one caller that knows
+	// this builder is an exact primary-key lookup the second-level
+	// cache can shortcut; every other constructor leaves it nil.
+	cacheID interface{}
+	// ex overrides the executor All/Get/Exec run against; TxFind is the
+	// one constructor that sets it, routing the builder through a Tx
+	// instead of the Connection's *sql.DB. Every other constructor
+	// leaves it nil, which execOr resolves to the Connection.
+	ex executor
+}
+
+// execOr returns qb.ex if TxFind set one, otherwise md's Connection.
+func (qb *QueryBuilder[T]) execOr(md *schema) executor {
+	if qb.ex != nil {
+		return qb.ex
+	}
+	return md.getConnection()
+}
+
+// newQueryBuilder starts a builder selecting from table. Passing an
+// empty table records an error instead of building an unusable query.
+func newQueryBuilder[T Entity](table string) *QueryBuilder[T] {
+	qb := &QueryBuilder[T]{table: table}
+	if table == "" {
+		return qb.fail(fmt.Errorf("orm: table name cannot be empty"))
+	}
+	return qb
+}
+
+func (qb *QueryBuilder[T]) fail(err error) *QueryBuilder[T] {
+	if qb.err == nil {
+		qb.err = err
+	}
+	return qb
+}
+
+// Where adds a filter built from cond (e.g. "approved = ?") and its
+// positional args.
+func (qb *QueryBuilder[T]) Where(cond string, args ...interface{}) *QueryBuilder[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.wheres = append(qb.wheres, cond)
+	qb.args = append(qb.args, args...)
+	return qb
+}
+
+// WhereCond adds a filter built from a typed Cond tree, rendered against
+// T's dialect, as an alternative to the string-based Where above.
+func (qb *QueryBuilder[T]) WhereCond(cond Cond) *QueryBuilder[T] {
+	if qb.err != nil {
+		return qb
+	}
+	if qb.phIndex == 0 {
+		qb.phIndex = 1
+	}
+	whereSQL, whereArgs := cond.render(getSchemaFor(*new(T)).dialect, &qb.phIndex)
+	qb.wheres = append(qb.wheres, whereSQL)
+	qb.args = append(qb.args, whereArgs...)
+	return qb
+}
+
+// Preload queues a relation, or a dotted relation path such as
+// "Comments.Author", to be eager-loaded onto every row a terminal All
+// or Get call returns, instead of being fetched lazily per row. A path
+// segment names the relation the same way schema.relations already
+// keys it -- the target table, matched case-insensitively -- and is
+// bucketed back onto the owning struct's field of that same name.
+func (qb *QueryBuilder[T]) Preload(paths ...string) *QueryBuilder[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.preloads = append(qb.preloads, paths...)
+	return qb
+}
+
+// PreloadContext behaves like Preload but runs the preload queries
+// against ctx instead of whatever context the terminal All/Get call is
+// given, so they can be cancelled or timed out independently.
+func (qb *QueryBuilder[T]) PreloadContext(ctx context.Context, paths ...string) *QueryBuilder[T] {
+	qb.preloadCtx = ctx
+	return qb.Preload(paths...)
+}
+
+func (qb *QueryBuilder[T]) preloadContextOr(ctx context.Context) context.Context {
+	if qb.preloadCtx != nil {
+		return qb.preloadCtx
+	}
+	return ctx
+}
+
+// OrderBy appends an ORDER BY clause, e.g. "created_at DESC".
+func (qb *QueryBuilder[T]) OrderBy(columns ...string) *QueryBuilder[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.orderBy = append(qb.orderBy, columns...)
+	return qb
+}
+
+// Limit caps the number of rows returned.
+func (qb *QueryBuilder[T]) Limit(n int) *QueryBuilder[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.limit = n
+	qb.limited = true
+	return qb
+}
+
+// Offset skips the first n rows that would otherwise be returned.
+func (qb *QueryBuilder[T]) Offset(n int) *QueryBuilder[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.offset = n
+	qb.offsetSet = true
+	return qb
+}
+
+// Select restricts the selected columns; omit it to select every mapped
+// column for T.
+func (qb *QueryBuilder[T]) Select(cols ...string) *QueryBuilder[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.cols = cols
+	return qb
+}
+
+// Join appends a JOIN clause of kind "INNER", "LEFT", "RIGHT" or "FULL
+// OUTER" against table, e.g. Join("INNER", "authors", "authors.id = posts.author_id").
+func (qb *QueryBuilder[T]) Join(kind, table, on string) *QueryBuilder[T] {
+	if qb.err != nil {
+		return qb
+	}
+	switch kind {
+	case "INNER", "LEFT", "RIGHT", "FULL OUTER":
+		qb.joins = append(qb.joins, joinSpec{kind: kind, table: table, on: on})
+	default:
+		qb.fail(fmt.Errorf("orm: unknown join kind %q", kind))
+	}
+	return qb
+}
+
+func (qb *QueryBuilder[T]) build() (string, []interface{}, error) {
+	if qb.err != nil {
+		return "", nil, qb.err
+	}
+
+	cols := qb.cols
+	if len(cols) == 0 {
+		cols = getSchemaFor(*new(T)).Columns(true)
+	}
+
+	q := builder.NewQuery().Table(qb.table)
+	q.Select(cols...)
+	if len(qb.wheres) > 0 {
+		// A single whereClause is joined with " " between its own
+		// conds, so every extra condition must be folded into one
+		// clause here rather than issued as separate Where calls.
+		q.Where(strings.Join(qb.wheres, " AND "))
+	}
+	if len(qb.orderBy) > 0 {
+		q.OrderBy(qb.orderBy...)
+	}
+	if qb.limited {
+		q.Limit(qb.limit)
+	}
+	if qb.offsetSet {
+		q.Offset(qb.offset)
+	}
+	for _, j := range qb.joins {
+		switch j.kind {
+		case "INNER":
+			q.InnerJoin(j.table).On(j.on)
+		case "LEFT":
+			q.LeftJoin(j.table).On(j.on)
+		case "RIGHT":
+			q.RightJoin(j.table).On(j.on)
+		case "FULL OUTER":
+			q.FullOuterJoin(j.table).On(j.on)
+		}
+	}
+
+	sqlStr, err := q.SQL()
+	if err != nil {
+		return "", nil, err
+	}
+	return sqlStr, qb.args, nil
+}
+
+// All runs the query and returns every matching row, with any queued
+// Preload paths eager-loaded onto them.
+func (qb *QueryBuilder[T]) All(ctx context.Context) ([]T, error) {
+	q, args, err := qb.build()
+	if err != nil {
+		return nil, err
+	}
+	md := getSchemaFor(*new(T))
+	var out []T
+	if err := bindContext[T](ctx, qb.execOr(md), &out, q, args); err != nil {
+		return nil, err
+	}
+	if len(qb.preloads) > 0 {
+		if err := preloadInto(qb.preloadContextOr(ctx), md, reflect.ValueOf(&out).Elem(), qb.preloads); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Get runs the query and binds the first matching row, with any queued
+// Preload paths eager-loaded onto it.
+func (qb *QueryBuilder[T]) Get(ctx context.Context) (T, error) {
+	out := new(T)
+	md := getSchemaFor(*out)
+	if qb.cacheID != nil {
+		if cacher := cacherFor(md); cacher != nil && isCacheable(*out) {
+			if bean, ok := cacher.GetBean(qb.table, qb.cacheID); ok {
+				if row, ok := bean.(T); ok {
+					if len(qb.preloads) == 0 {
+						return row, nil
+					}
+					// A cached bean still needs its queued Preload paths
+					// filled in -- the cache only ever stores the row
+					// itself, never its relations -- so run the same
+					// preloadInto pass the cold path below does instead
+					// of returning it unpopulated.
+					owners := reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(row)), 1, 1)
+					owners.Index(0).Set(reflect.ValueOf(row))
+					if err := preloadInto(qb.preloadContextOr(ctx), md, owners, qb.preloads); err != nil {
+						return row, err
+					}
+					return owners.Index(0).Interface().(T), nil
+				}
+			}
+		}
+	}
+	q, args, err := qb.build()
+	if err != nil {
+		return *out, err
+	}
+	if err := bindContext[T](ctx, qb.execOr(md), out, q, args); err != nil {
+		return *out, err
+	}
+	if len(qb.preloads) > 0 {
+		row := reflect.ValueOf(out).Elem()
+		owners := reflect.MakeSlice(reflect.SliceOf(row.Type()), 1, 1)
+		owners.Index(0).Set(row)
+		if err := preloadInto(qb.preloadContextOr(ctx), md, owners, qb.preloads); err != nil {
+			return *out, err
+		}
+		row.Set(owners.Index(0))
+	}
+	return *out, nil
+}
+
+// Exec runs the built statement for its side effects and reports rows
+// affected.
+func (qb *QueryBuilder[T]) Exec(ctx context.Context) (int64, error) {
+	q, args, err := qb.build()
+	if err != nil {
+		return 0, err
+	}
+	md := getSchemaFor(*new(T))
+	res, err := qb.execOr(md).ExecContext(ctx, q, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
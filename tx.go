@@ -0,0 +1,207 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/golobby/orm/querybuilder"
+)
+
+// executor is anything the CRUD helpers can run SQL against: a
+// *Connection delegates to its *sql.DB, a *Tx delegates to its in-flight
+// *sql.Tx. Writing the helpers against this interface instead of
+// *sql.DB directly is what lets Insert/Update/Delete/Find/Query/Exec
+// keep working unchanged for non-transactional callers while Tx reuses
+// the exact same logic.
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func (c *Connection) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.Connection.ExecContext(ctx, query, args...)
+}
+
+func (c *Connection) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.Connection.QueryContext(ctx, query, args...)
+}
+
+// Tx is a transaction opened by Connection.Transaction. It exposes the
+// same Insert/Update/Delete/Save surface as the package's free functions
+// (TxFind/TxQuery/TxQueryRaw/TxExec/TxExecRaw cover the generic ones Go
+// doesn't allow to be declared as methods), routed through the
+// underlying *sql.Tx instead of the Connection's *sql.DB.
+type Tx struct {
+	conn  *Connection
+	tx    *sql.Tx
+	depth int
+	// spSeq is shared by every Tx in the tree so nested SAVEPOINTs get
+	// distinct, monotonically increasing names regardless of which
+	// level opened them.
+	spSeq *int64
+	// after is shared by every Tx in the tree: AfterCommit/AfterRollback
+	// only fire once, at the outermost Connection.Transaction boundary,
+	// whichever nesting level queued them.
+	after *txCallbacks
+}
+
+type txCallbacks struct {
+	commit   []func(ctx context.Context)
+	rollback []func(ctx context.Context)
+}
+
+// AfterCommit queues fn to run once the outermost transaction in tx's
+// tree commits successfully.
+func (tx *Tx) AfterCommit(fn func(ctx context.Context)) {
+	tx.after.commit = append(tx.after.commit, fn)
+}
+
+// AfterRollback queues fn to run if the outermost transaction in tx's
+// tree ends in a rollback, whether from a returned error or a panic.
+func (tx *Tx) AfterRollback(fn func(ctx context.Context)) {
+	tx.after.rollback = append(tx.after.rollback, fn)
+}
+
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return tx.tx.ExecContext(ctx, query, args...)
+}
+
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.tx.QueryContext(ctx, query, args...)
+}
+
+// txExecutor reports whether ex is a *Tx, so helpers shared between
+// Connection and Tx call sites can skip populating the second-level
+// cache with rows that a rollback could still undo.
+func txExecutor(ex executor) bool {
+	_, ok := ex.(*Tx)
+	return ok
+}
+
+// Transaction opens a transaction on d and runs fn against it: a nil
+// return commits, a non-nil return rolls back and is propagated, and a
+// panic inside fn rolls back before being re-raised. AfterCommit and
+// AfterRollback callbacks queued anywhere in the tree -- including
+// inside nested Tx.Transaction calls -- flush once the outcome here is
+// known.
+func (d *Connection) Transaction(ctx context.Context, fn func(tx *Tx) error) error {
+	sqlTx, err := d.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	var seq int64
+	tx := &Tx{conn: d, tx: sqlTx, spSeq: &seq, after: &txCallbacks{}}
+
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		_ = sqlTx.Rollback()
+		r := recover()
+		runTxCallbacks(ctx, tx.after.rollback)
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return err
+	}
+	committed = true
+	runTxCallbacks(ctx, tx.after.commit)
+	return nil
+}
+
+// Transaction, called on an already-open Tx, nests via a SAVEPOINT
+// instead of opening a second *sql.Tx: a nil return from fn releases the
+// savepoint, a non-nil return or panic rolls back to it (and is
+// propagated/re-raised), leaving the outer transaction free to recover
+// or continue. AfterCommit/AfterRollback queued inside fn still only
+// fire at the outermost Connection.Transaction boundary.
+func (tx *Tx) Transaction(ctx context.Context, fn func(tx *Tx) error) error {
+	sp := fmt.Sprintf("sp_%d", atomic.AddInt64(tx.spSeq, 1))
+	dialect := tx.conn.Dialect
+	if _, err := tx.tx.ExecContext(ctx, savepointSQL(dialect, sp)); err != nil {
+		return err
+	}
+	child := &Tx{conn: tx.conn, tx: tx.tx, depth: tx.depth + 1, spSeq: tx.spSeq, after: tx.after}
+
+	released := false
+	defer func() {
+		if released {
+			return
+		}
+		_, _ = tx.tx.ExecContext(ctx, rollbackToSavepointSQL(dialect, sp))
+		if r := recover(); r != nil {
+			panic(r)
+		}
+	}()
+
+	if err := fn(child); err != nil {
+		return err
+	}
+	if _, err := tx.tx.ExecContext(ctx, releaseSavepointSQL(dialect, sp)); err != nil {
+		return err
+	}
+	released = true
+	return nil
+}
+
+// savepointSQL, rollbackToSavepointSQL and releaseSavepointSQL render
+// their statements against d the same way cond.go's nextPlaceholder
+// does, so a dialect that ever needs non-ANSI savepoint syntax has a
+// single place to override it instead of Tx.Transaction hardcoding
+// strings. All three dialects querybuilder.Dialects declares use
+// identical ANSI syntax today.
+func savepointSQL(d *querybuilder.Dialect, name string) string {
+	return "SAVEPOINT " + name
+}
+
+func rollbackToSavepointSQL(d *querybuilder.Dialect, name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+
+func releaseSavepointSQL(d *querybuilder.Dialect, name string) string {
+	return "RELEASE SAVEPOINT " + name
+}
+
+func runTxCallbacks(ctx context.Context, fns []func(ctx context.Context)) {
+	for _, fn := range fns {
+		fn(ctx)
+	}
+}
+
+// Insert mirrors the package-level Insert, run against tx.
+func (tx *Tx) Insert(obj Entity) error {
+	return insertVia(context.Background(), tx, obj)
+}
+
+// InsertAll mirrors the package-level InsertAll, run against tx.
+func (tx *Tx) InsertAll(objs ...Entity) error {
+	return insertAllVia(context.Background(), tx, objs...)
+}
+
+// Update mirrors the package-level Update, run against tx.
+func (tx *Tx) Update(obj Entity) error {
+	return updateVia(context.Background(), tx, obj)
+}
+
+// Delete mirrors the package-level Delete, run against tx.
+func (tx *Tx) Delete(obj Entity) error {
+	return deleteVia(context.Background(), tx, obj)
+}
+
+// Save mirrors the package-level Save, run against tx.
+func (tx *Tx) Save(obj Entity) error {
+	if reflect.ValueOf(genericGetPKValue(obj)).IsZero() {
+		return tx.Insert(obj)
+	}
+	return tx.Update(obj)
+}
@@ -0,0 +1,19 @@
+package orm
+
+import "testing"
+
+// TestSavepointSQL guards the chunk0-6 architecture fix: savepoint SQL
+// is generated through dialect-aware helpers instead of hardcoded
+// strings in Tx.Transaction, even though every dialect currently
+// renders the same ANSI syntax.
+func TestSavepointSQL(t *testing.T) {
+	if got, want := savepointSQL(pgDialect, "sp_1"), "SAVEPOINT sp_1"; got != want {
+		t.Errorf("savepointSQL = %q, want %q", got, want)
+	}
+	if got, want := rollbackToSavepointSQL(pgDialect, "sp_1"), "ROLLBACK TO SAVEPOINT sp_1"; got != want {
+		t.Errorf("rollbackToSavepointSQL = %q, want %q", got, want)
+	}
+	if got, want := releaseSavepointSQL(pgDialect, "sp_1"), "RELEASE SAVEPOINT sp_1"; got != want {
+		t.Errorf("releaseSavepointSQL = %q, want %q", got, want)
+	}
+}